@@ -2,89 +2,296 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/oj-lab/go-webmods/app"
 	config "github.com/oj-lab/reborn/configs"
+	"github.com/oj-lab/reborn/internal/certs"
+	"github.com/oj-lab/reborn/internal/diagnostics"
+	"github.com/oj-lab/reborn/internal/logging"
+	"github.com/oj-lab/reborn/internal/metrics"
 	"github.com/oj-lab/reborn/internal/middlewares"
 	"github.com/oj-lab/reborn/internal/routers"
 	"github.com/oj-lab/reborn/internal/services"
+	"github.com/oj-lab/reborn/internal/telemetry"
 )
 
 func init() {
 	app.SetCMDName("web")
 	cwd, _ := os.Getwd()
+	config.PrepareEnv()
 	app.Init(cwd)
+	logging.Setup()
 }
 
+// Process exit codes
+const (
+	exitOK      = 0
+	exitFailure = 1
+)
+
+// redirectReadHeaderTimeout bounds reading requests on the HTTP redirect
+// listener
+const redirectReadHeaderTimeout = 10 * time.Second
+
 func main() {
-	cfg := config.Load()
+	os.Exit(run())
+}
+
+// run starts the application, blocks until it is asked to stop or a server
+// fails, and returns the process exit code
+func run() int {
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		return exitFailure
+	}
+
+	// Refuse to start with an unsafe CORS policy
+	corsMiddleware, err := middlewares.CORS(cfg.CORS)
+	if err != nil {
+		slog.Error("Invalid CORS configuration", "error", err)
+		return exitFailure
+	}
+
+	// Load certificates before anything else so that a broken TLS setup
+	// aborts startup
+	var certManager *certs.Manager
+	if cfg.Server.TLS.Enabled {
+		certManager, err = certs.NewManager(cfg.Server.TLS)
+		if err != nil {
+			slog.Error("Invalid TLS configuration", "error", err)
+			return exitFailure
+		}
+		defer func() {
+			if err := certManager.Close(); err != nil {
+				slog.Error("Error closing certificate manager", "error", err)
+			}
+		}()
+	}
+
+	// Initialize tracing before any instrumented client is created
+	shutdownTracing, err := telemetry.InitTracing(context.Background(), cfg.Tracing)
+	if err != nil {
+		slog.Error("Failed to initialize tracing", "error", err)
+		return exitFailure
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Error("Error during tracing shutdown", "error", err)
+		}
+	}()
 
 	// Initialize service manager
 	serviceManager := services.NewServiceManager()
 	if err := serviceManager.Initialize(cfg); err != nil {
-		fmt.Printf("Failed to initialize services: %v\n", err)
-		os.Exit(1)
+		slog.Error("Failed to initialize services", "error", err)
+		return exitFailure
 	}
 
+	exitCode := exitOK
+	servicesStopped := false
+	stopServices := func(ctx context.Context) {
+		servicesStopped = true
+		if err := serviceManager.Shutdown(ctx); err != nil {
+			slog.Error("Error during service shutdown", "error", err)
+			exitCode = exitFailure
+		}
+	}
+	// Stop services when returning early because a server failed to start
 	defer func() {
-		if err := serviceManager.Shutdown(); err != nil {
-			fmt.Printf("Error during service shutdown: %v\n", err)
+		if !servicesStopped {
+			stopServices(context.Background())
 		}
 	}()
 
+	// Reconnect services when their settings change on disk
+	cwd, _ := os.Getwd()
+	if err := serviceManager.WatchConfig(filepath.Join(cwd, "configs")); err != nil {
+		slog.Warn("Config hot-reload disabled", "error", err)
+	}
+
+	connTracker := diagnostics.NewConnTracker()
 	e := echo.New()
+	// Startup is logged through slog instead of echo's banner
+	e.HideBanner = true
+	e.HidePort = true
+	// Server errors, e.g. failed certificate requests, go through slog too
+	e.StdLogger = logging.ServerErrorLog()
+	e.Server.ConnState = connTracker.ConnState
+	e.Server.ReadTimeout = cfg.Server.ReadTimeout
+	e.Server.ReadHeaderTimeout = cfg.Server.ReadHeaderTimeout
+	e.Server.WriteTimeout = cfg.Server.WriteTimeout
+	e.Server.IdleTimeout = cfg.Server.IdleTimeout
 
 	// Set custom error handler
 	e.HTTPErrorHandler = middlewares.ErrorHandler
 
 	// Add middlewares
 	e.Use(middlewares.RequestID())
+	e.Use(middlewares.Tracing(cfg.Tracing.ServiceName))
+	e.Use(middlewares.Metrics())
+	e.Use(middlewares.Compress(cfg.Compression))
 	e.Use(middlewares.Logger())
 	e.Use(middlewares.Recover())
-	e.Use(middlewares.CORS())
-	e.Use(middlewares.RateLimiter())
-
-	// Add health check endpoint
-	e.GET("/health", func(c echo.Context) error {
-		health := serviceManager.HealthCheck()
-		return c.JSON(200, map[string]any{
-			"status":   "ok",
-			"services": health,
-		})
-	})
+	e.Use(corsMiddleware)
+	e.Use(middlewares.HSTS(cfg.Server.HSTS))
+	e.Use(middlewares.Security(cfg.Security))
 
 	// Register routes
-	routers.RegisterAPIv1Routes(e, serviceManager)
-	routers.RegisterAuthRoutes(e, serviceManager)
-	routers.RegisterPageRoutes(e, serviceManager)
+	routers.RegisterHealthRoutes(e, cfg, serviceManager)
+	routers.RegisterMetricsRoutes(e, cfg)
+	routers.RegisterDebugRoutes(e, cfg, serviceManager, connTracker)
+	routers.RegisterAPIv1Routes(e, cfg, serviceManager)
+	routers.RegisterAuthRoutes(e, cfg, serviceManager)
+	if err := routers.RegisterPageRoutes(e, cfg, serviceManager); err != nil {
+		slog.Error("Invalid website configuration", "error", err)
+		return exitFailure
+	}
 
-	// Start server in a goroutine
-	go func() {
-		if err := e.Start(fmt.Sprintf(":%d", cfg.Server.Port)); err != nil {
-			fmt.Printf("Server startup error: %v\n", err)
+	// Bind before serving so that e.g. a port in use aborts startup
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Server.Port))
+	if err != nil {
+		slog.Error("Failed to listen", "port", cfg.Server.Port, "error", err)
+		return exitFailure
+	}
+
+	// Additional servers, each on its own listener
+	var auxServers []auxServer
+	if certManager != nil && cfg.Server.TLS.RedirectPort != 0 {
+		// Redirect plain HTTP to HTTPS, which also answers ACME challenges
+		auxServers = append(auxServers, auxServer{
+			name: "redirect",
+			addr: fmt.Sprintf(":%d", cfg.Server.TLS.RedirectPort),
+			server: &http.Server{
+				Handler:           certManager.RedirectHandler(cfg.Server.Port),
+				ReadHeaderTimeout: redirectReadHeaderTimeout,
+				ErrorLog:          logging.ServerErrorLog(),
+			},
+		})
+	}
+	if cfg.Metrics.Enabled && cfg.Metrics.ListenAddress != "" {
+		auxServers = append(auxServers, auxServer{
+			name: "metrics",
+			addr: cfg.Metrics.ListenAddress,
+			server: metrics.NewServer(
+				cfg.Metrics.ListenAddress,
+				cfg.Metrics.Path,
+				cfg.Metrics.BearerToken,
+			),
+		})
+	}
+	if cfg.Debug.Enabled && cfg.Debug.ListenAddress != "" {
+		auxServers = append(auxServers, auxServer{
+			name:   "debug",
+			addr:   cfg.Debug.ListenAddress,
+			server: routers.NewDebugServer(cfg, serviceManager, connTracker),
+		})
+	}
+
+	serverErr := make(chan error, 1+len(auxServers))
+	if certManager != nil {
+		e.Server.TLSConfig = certManager.TLSConfig()
+		e.TLSListener = tls.NewListener(listener, e.Server.TLSConfig)
+		go func() {
+			slog.Info("HTTPS server listening", "address", listener.Addr().String())
+			if err := e.StartServer(e.Server); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- fmt.Errorf("https server: %w", err)
+			}
+		}()
+	} else {
+		e.Listener = listener
+		go func() {
+			slog.Info("HTTP server listening", "address", listener.Addr().String())
+			if err := e.Start(""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- fmt.Errorf("http server: %w", err)
+			}
+		}()
+	}
+
+	for i, aux := range auxServers {
+		if err := aux.start(serverErr); err != nil {
+			slog.Error("Failed to listen", "server", aux.name, "address", aux.addr, "error", err)
+			_ = e.Close()
+			for _, started := range auxServers[:i] {
+				_ = started.server.Close()
+			}
+			return exitFailure
 		}
-	}()
+	}
 
-	// Wait for interrupt signal to gracefully shutdown the server
+	// Wait for a termination signal or a failing server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
-	<-quit
+	select {
+	case sig := <-quit:
+		slog.Info("Shutting down server", "signal", sig.String())
+	case err := <-serverErr:
+		slog.Error("Server failed, shutting down", "error", err)
+		exitCode = exitFailure
+	}
 
-	fmt.Println("Shutting down server...")
+	// Fail readiness first and give load balancers time to notice before
+	// connections are drained. A second signal skips the wait.
+	serviceManager.BeginShutdown()
+	if cfg.Server.PreStopDelay > 0 {
+		slog.Info("Waiting before draining connections", "delay", cfg.Server.PreStopDelay)
+		select {
+		case <-time.After(cfg.Server.PreStopDelay):
+		case <-quit:
+		}
+	}
 
-	// Shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := e.Shutdown(ctx); err != nil {
-		fmt.Printf("Server shutdown error: %v\n", err)
+		slog.Error("Server shutdown error", "error", err)
+		exitCode = exitFailure
+	}
+	for _, aux := range auxServers {
+		if err := aux.server.Shutdown(ctx); err != nil {
+			slog.Error("Server shutdown error", "server", aux.name, "error", err)
+			exitCode = exitFailure
+		}
 	}
+	stopServices(ctx)
+
+	slog.Info("Server stopped", "exit_code", exitCode)
+	return exitCode
+}
 
-	fmt.Println("Server stopped")
+// auxServer is an HTTP server started next to the main one, such as the
+// metrics or debug server
+type auxServer struct {
+	name   string
+	addr   string
+	server *http.Server
+}
+
+// start binds the listener synchronously and serves in the background,
+// reporting failures on serverErr
+func (s auxServer) start(serverErr chan<- error) error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		slog.Info("Server listening", "server", s.name, "address", listener.Addr().String())
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- fmt.Errorf("%s server: %w", s.name, err)
+		}
+	}()
+	return nil
 }
@@ -1,43 +1,645 @@
 package config
 
-import "github.com/oj-lab/go-webmods/app"
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/gommon/bytes"
+	"github.com/oj-lab/go-webmods/app"
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultConfigName = "default"
+	// modeEnv selects the config file merged on top of the default one,
+	// mirroring go-webmods app initialization
+	modeEnv     = "MODE"
+	defaultMode = "development"
+)
 
 // Configuration keys constants
 const (
-	ServerPortKey         = "server.port"
-	AuthServiceAddressKey = "auth_service.address"
-	WebsiteDistPathKey    = "website.dist_path"
+	ServerPortKey                     = "server.port"
+	ServerShutdownTimeoutKey          = "server.shutdown_timeout"
+	ServerPreStopDelayKey             = "server.pre_stop_delay"
+	ServerTLSEnabledKey               = "server.tls.enabled"
+	ServerTLSCertFileKey              = "server.tls.cert_file"
+	ServerTLSKeyFileKey               = "server.tls.key_file"
+	ServerTLSRedirectPortKey          = "server.tls.redirect_port"
+	ServerACMEEnabledKey              = "server.tls.acme.enabled"
+	ServerACMEDomainsKey              = "server.tls.acme.domains"
+	ServerACMEEmailKey                = "server.tls.acme.email"
+	ServerACMECacheDirKey             = "server.tls.acme.cache_dir"
+	ServerACMEDirectoryURLKey         = "server.tls.acme.directory_url"
+	ServerACMECAFileKey               = "server.tls.acme.ca_file"
+	ServerHSTSEnabledKey              = "server.hsts.enabled"
+	ServerHSTSMaxAgeKey               = "server.hsts.max_age"
+	ServerHSTSIncludeSubdomainsKey    = "server.hsts.include_subdomains"
+	ServerHSTSPreloadKey              = "server.hsts.preload"
+	ServerReadTimeoutKey              = "server.read_timeout"
+	ServerReadHeaderTimeoutKey        = "server.read_header_timeout"
+	ServerWriteTimeoutKey             = "server.write_timeout"
+	ServerIdleTimeoutKey              = "server.idle_timeout"
+	ServerBodyLimitsKey               = "server.body_limits"
+	SecurityCSPKey                    = "security.content_security_policy"
+	SecurityCSPReportOnlyKey          = "security.csp_report_only"
+	SecurityFrameOptionsKey           = "security.frame_options"
+	SecurityReferrerPolicyKey         = "security.referrer_policy"
+	SecurityPermissionsPolicyKey      = "security.permissions_policy"
+	SecurityContentTypeNosniffKey     = "security.content_type_nosniff"
+	CompressionEnabledKey             = "compression.enabled"
+	CompressionMinSizeKey             = "compression.min_size"
+	CompressionContentTypesKey        = "compression.content_types"
+	DebugEnabledKey                   = "debug.enabled"
+	DebugListenAddressKey             = "debug.listen_address"
+	LogLevelKey                       = "log.level"
+	LogFormatKey                      = "log.format"
+	AuthServiceAddressKey             = "auth_service.address"
+	AuthServiceAddressesKey           = "auth_service.addresses"
+	AuthServiceLoadBalancingKey       = "auth_service.load_balancing"
+	AuthServiceHealthCheckIntervalKey = "auth_service.health_check_interval"
+	AuthServiceHealthCheckTimeoutKey  = "auth_service.health_check_timeout"
+	AuthServiceTimeoutKey             = "auth_service.timeout"
+	AuthServiceMethodTimeoutsKey      = "auth_service.method_timeouts"
+	AuthServiceRetryMaxAttemptsKey    = "auth_service.retry.max_attempts"
+	AuthServiceRetryInitialBackoffKey = "auth_service.retry.initial_backoff"
+	AuthServiceRetryMaxBackoffKey     = "auth_service.retry.max_backoff"
+	AuthServiceBreakerThresholdKey    = "auth_service.circuit_breaker.failure_threshold"
+	AuthServiceBreakerOpenTimeoutKey  = "auth_service.circuit_breaker.open_timeout"
+	AuthServiceTLSEnabledKey          = "auth_service.tls.enabled"
+	AuthServiceTLSCAFileKey           = "auth_service.tls.ca_file"
+	AuthServiceTLSCertFileKey         = "auth_service.tls.cert_file"
+	AuthServiceTLSKeyFileKey          = "auth_service.tls.key_file"
+	AuthServiceTLSServerNameKey       = "auth_service.tls.server_name"
+	WebsiteDistPathKey                = "website.dist_path"
+	WebsiteExcludedPrefixesKey        = "website.excluded_prefixes"
+	WebsiteAssetPrefixesKey           = "website.asset_prefixes"
+	WebsiteTitleKey                   = "website.title"
+	WebsiteDescriptionKey             = "website.description"
+	WebsiteImageKey                   = "website.image"
+	WebsiteDevProxyURLKey             = "website.dev_proxy_url"
+	WebsitePublicURLKey               = "website.public_url"
+	WebsiteLoginProvidersKey          = "website.login_providers"
+	WebsiteFeaturesKey                = "website.features"
+	TracingExporterKey                = "tracing.exporter"
+	TracingEndpointKey                = "tracing.endpoint"
+	TracingInsecureKey                = "tracing.insecure"
+	TracingSampleRatioKey             = "tracing.sample_ratio"
+	TracingServiceNameKey             = "tracing.service_name"
+	MetricsEnabledKey                 = "metrics.enabled"
+	MetricsPathKey                    = "metrics.path"
+	MetricsListenAddressKey           = "metrics.listen_address"
+	MetricsBearerTokenKey             = "metrics.bearer_token"
+	RateLimitStoreKey                 = "rate_limit.store"
+	RateLimitRedisURLsKey             = "rate_limit.redis.urls"
+	RateLimitRedisPasswordKey         = "rate_limit.redis.password"
+	RateLimitRedisKeyPrefixKey        = "rate_limit.redis.key_prefix"
+	RateLimitRulesKey                 = "rate_limit.rules"
+	CORSKey                           = "cors"
+	CORSGroupsKey                     = "cors.groups"
+)
+
+// Keys of a CORS policy, relative to the [cors] table or one of its groups
+const (
+	corsPathPrefixKey       = "path_prefix"
+	corsAllowOriginsKey     = "allow_origins"
+	corsAllowMethodsKey     = "allow_methods"
+	corsAllowHeadersKey     = "allow_headers"
+	corsExposeHeadersKey    = "expose_headers"
+	corsAllowCredentialsKey = "allow_credentials"
+	corsMaxAgeKey           = "max_age"
 )
 
 type Config struct {
 	Server      ServerConfig
 	AuthService AuthServiceConfig
 	Website     WebsiteConfig
+	Tracing     TracingConfig
+	Metrics     MetricsConfig
+	RateLimit   RateLimitConfig
+	CORS        CORSConfig
+	Security    SecurityConfig
+	Compression CompressionConfig
+	Debug       DebugConfig
 }
 
 type ServerConfig struct {
 	Port uint
+	// ShutdownTimeout bounds draining connections and stopping services
+	ShutdownTimeout time.Duration
+	// PreStopDelay keeps serving after readiness fails on shutdown, so that
+	// load balancers stop routing new requests first
+	PreStopDelay time.Duration
+	TLS          ServerTLSConfig
+	HSTS         HSTSConfig
+	// Timeouts of the HTTP server, zero means no timeout
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// BodyLimits maps a route group, e.g. "api", to the maximum request body
+	// size in bytes
+	BodyLimits map[string]int64
+}
+
+// ServerTLSConfig configures HTTPS on the main server port
+type ServerTLSConfig struct {
+	Enabled bool
+	// CertFile and KeyFile are reloaded when they change on disk, they are
+	// not used when ACME is enabled
+	CertFile string
+	KeyFile  string
+	// RedirectPort serves plain HTTP redirects to HTTPS, and ACME HTTP-01
+	// challenges, when not zero
+	RedirectPort uint
+	ACME         ACMEConfig
+}
+
+// ACMEConfig configures obtaining and renewing certificates automatically
+type ACMEConfig struct {
+	Enabled bool
+	// Domains are the only host names certificates are requested for
+	Domains  []string
+	Email    string
+	CacheDir string
+	// DirectoryURL selects the ACME server, Let's Encrypt when empty
+	DirectoryURL string
+	// CAFile is trusted for connections to the ACME server, e.g. the
+	// certificate of a local pebble instance
+	CAFile string
+}
+
+// HSTSConfig configures the Strict-Transport-Security header sent on HTTPS
+// responses
+type HSTSConfig struct {
+	Enabled           bool
+	MaxAge            time.Duration
+	IncludeSubdomains bool
+	Preload           bool
 }
 
 type AuthServiceConfig struct {
+	// Address is a single host:port or a gRPC target such as dns:///host:port
 	Address string
+	// Addresses lists replicas served by a static resolver, taking
+	// precedence over Address when set
+	Addresses []string
+	// LoadBalancing is one of "pick_first", "round_robin" or "least_request"
+	LoadBalancing       string
+	HealthCheckInterval time.Duration
+	HealthCheckTimeout  time.Duration
+	// Timeout is the default deadline for calls without a method override
+	Timeout        time.Duration
+	MethodTimeouts map[string]time.Duration
+	Retry          RetryConfig
+	CircuitBreaker CircuitBreakerConfig
+	TLS            TLSConfig
+}
+
+// Supported values of AuthServiceConfig.LoadBalancing
+const (
+	LoadBalancingPickFirst    = "pick_first"
+	LoadBalancingRoundRobin   = "round_robin"
+	LoadBalancingLeastRequest = "least_request"
+)
+
+// TLSConfig configures transport security for a gRPC client
+type TLSConfig struct {
+	Enabled    bool
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+}
+
+// RetryConfig controls retries of idempotent gRPC calls
+type RetryConfig struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// CircuitBreakerConfig controls when calls to a downstream service fail fast
+type CircuitBreakerConfig struct {
+	FailureThreshold int
+	OpenTimeout      time.Duration
 }
 
 type WebsiteConfig struct {
+	// DistPath overrides the embedded website build when it exists
 	DistPath string
+	// ExcludedPrefixes are URL path prefixes never served from the build
+	ExcludedPrefixes []string
+	// AssetPrefixes hold content-hashed files, cached forever and answered
+	// with 404 instead of the SPA shell when missing
+	AssetPrefixes []string
+	// Title, PublicURL, LoginProviders and Features are handed to the
+	// frontend in index.html
+	Title          string
+	PublicURL      string
+	LoginProviders []string
+	Features       map[string]bool
+	// Description and Image are the defaults of link previews. Image may
+	// be relative to PublicURL, and is then dropped while PublicURL is empty.
+	Description string
+	Image       string
+	// DevProxyURL serves pages and files from a frontend dev server such
+	// as Vite instead of the build, for development only
+	DevProxyURL string
 }
 
-func Load() Config {
+// MetricsConfig configures the Prometheus metrics endpoint
+type MetricsConfig struct {
+	Enabled bool
+	Path    string
+	// ListenAddress serves metrics on a separate listener when set,
+	// instead of on the main server
+	ListenAddress string
+	// BearerToken, when set, must be presented to scrape metrics. It is
+	// required on the main server, which is usually public.
+	BearerToken string
+}
+
+// RateLimitConfig configures request rate limiting per route group
+type RateLimitConfig struct {
+	// Store is one of "memory" or "redis"
+	Store string
+	Redis RedisConfig
+	// Rules maps a route group, e.g. "api", to its limit
+	Rules map[string]RateLimitRule
+}
+
+// Supported values of RateLimitConfig.Store
+const (
+	RateLimitStoreMemory = "memory"
+	RateLimitStoreRedis  = "redis"
+)
+
+// Names of route groups, which select their rate limit rule and body limit
+const (
+	RouteGroupDefault    = "default"
+	RouteGroupAuth       = "auth"
+	RouteGroupAPI        = "api"
+	RouteGroupSubmission = "submission"
+)
+
+// RateLimitRule allows Requests per Window for each user or client IP
+type RateLimitRule struct {
+	Requests int
+	Window   time.Duration
+}
+
+// RedisConfig configures a connection to a Redis-protocol server
+type RedisConfig struct {
+	// URLs lists host:port addresses, more than one selects cluster mode
+	URLs      []string
+	Password  string
+	KeyPrefix string
+}
+
+// CORSConfig configures cross-origin requests, with overrides for groups of
+// routes
+type CORSConfig struct {
+	Policy CORSPolicy
+	// Groups override fields of Policy for requests under their PathPrefix
+	Groups map[string]CORSPolicy
+}
+
+// CORSPolicy describes which cross-origin requests are allowed
+type CORSPolicy struct {
+	// PathPrefix selects the requests a group policy applies to
+	PathPrefix string
+	// AllowOrigins lists exact origins, "*" or wildcard subdomains such as
+	// "https://*.example.com"
+	AllowOrigins     []string
+	AllowMethods     []string
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache preflight responses
+	MaxAge time.Duration
+}
+
+// SecurityConfig configures the security headers sent with every response
+type SecurityConfig struct {
+	// ContentSecurityPolicy may contain "{nonce}", which is replaced by a
+	// fresh nonce for every request. Empty disables the header.
+	ContentSecurityPolicy string
+	// CSPReportOnly only reports violations instead of blocking them
+	CSPReportOnly      bool
+	FrameOptions       string
+	ReferrerPolicy     string
+	PermissionsPolicy  string
+	ContentTypeNosniff bool
+}
+
+// CSPNoncePlaceholder is replaced by the request nonce in
+// SecurityConfig.ContentSecurityPolicy
+const CSPNoncePlaceholder = "{nonce}"
+
+// CompressionConfig configures compressing responses on the fly. Static
+// files are served from precompressed siblings instead when available.
+type CompressionConfig struct {
+	Enabled bool
+	// MinSize in bytes below which responses are sent uncompressed
+	MinSize int64
+	// ContentTypes lists the media types worth compressing
+	ContentTypes []string
+}
+
+// DebugConfig configures the runtime diagnostics endpoints, such as pprof
+type DebugConfig struct {
+	// Enabled serves diagnostics under /admin/debug to admins
+	Enabled bool
+	// ListenAddress also serves them without authentication on a separate
+	// listener when set, e.g. "127.0.0.1:6060"
+	ListenAddress string
+}
+
+// Supported values of TracingConfig.Exporter
+const (
+	TracingExporterOff    = "off"
+	TracingExporterOTLP   = "otlp"
+	TracingExporterStdout = "stdout"
+)
+
+// TracingConfig configures OpenTelemetry trace export
+type TracingConfig struct {
+	// Exporter is one of "otlp", "stdout" or "off"
+	Exporter string
+	// Endpoint is the OTLP gRPC collector address
+	Endpoint    string
+	Insecure    bool
+	SampleRatio float64
+	ServiceName string
+}
+
+// Load reads the configuration from the defaults, the config files and the
+// environment, and validates it. Every setting can be overridden with a
+// REBORN_ variable, e.g. REBORN_SERVER_PORT for server.port, or read from
+// the file named by its _FILE variant, e.g. REBORN_METRICS_BEARER_TOKEN_FILE.
+// The log settings only take effect when PrepareEnv ran before app.Init.
+func Load() (Config, error) {
+	v := app.Config()
+	setDefaults(v)
+	if err := applyEnv(v); err != nil {
+		return Config{}, err
+	}
+	// Reported first, as wrongly typed settings read as zero values
+	if err := errors.Join(checkTypes(v), validateLog(v)); err != nil {
+		return Config{}, err
+	}
+
 	cfg := Config{
 		Server: ServerConfig{
-			Port: app.Config().GetUint(ServerPortKey),
+			Port:            app.Config().GetUint(ServerPortKey),
+			ShutdownTimeout: app.Config().GetDuration(ServerShutdownTimeoutKey),
+			PreStopDelay:    app.Config().GetDuration(ServerPreStopDelayKey),
+			TLS: ServerTLSConfig{
+				Enabled:      app.Config().GetBool(ServerTLSEnabledKey),
+				CertFile:     app.Config().GetString(ServerTLSCertFileKey),
+				KeyFile:      app.Config().GetString(ServerTLSKeyFileKey),
+				RedirectPort: app.Config().GetUint(ServerTLSRedirectPortKey),
+				ACME: ACMEConfig{
+					Enabled:      app.Config().GetBool(ServerACMEEnabledKey),
+					Domains:      app.Config().GetStringSlice(ServerACMEDomainsKey),
+					Email:        app.Config().GetString(ServerACMEEmailKey),
+					CacheDir:     app.Config().GetString(ServerACMECacheDirKey),
+					DirectoryURL: app.Config().GetString(ServerACMEDirectoryURLKey),
+					CAFile:       app.Config().GetString(ServerACMECAFileKey),
+				},
+			},
+			HSTS: HSTSConfig{
+				Enabled:           app.Config().GetBool(ServerHSTSEnabledKey),
+				MaxAge:            app.Config().GetDuration(ServerHSTSMaxAgeKey),
+				IncludeSubdomains: app.Config().GetBool(ServerHSTSIncludeSubdomainsKey),
+				Preload:           app.Config().GetBool(ServerHSTSPreloadKey),
+			},
+			ReadTimeout:       app.Config().GetDuration(ServerReadTimeoutKey),
+			ReadHeaderTimeout: app.Config().GetDuration(ServerReadHeaderTimeoutKey),
+			WriteTimeout:      app.Config().GetDuration(ServerWriteTimeoutKey),
+			IdleTimeout:       app.Config().GetDuration(ServerIdleTimeoutKey),
+			BodyLimits:        loadByteSizeMap(ServerBodyLimitsKey),
 		},
 		AuthService: AuthServiceConfig{
-			Address: app.Config().GetString(AuthServiceAddressKey),
+			Address:             app.Config().GetString(AuthServiceAddressKey),
+			Addresses:           app.Config().GetStringSlice(AuthServiceAddressesKey),
+			LoadBalancing:       app.Config().GetString(AuthServiceLoadBalancingKey),
+			HealthCheckInterval: app.Config().GetDuration(AuthServiceHealthCheckIntervalKey),
+			HealthCheckTimeout:  app.Config().GetDuration(AuthServiceHealthCheckTimeoutKey),
+			Timeout:             app.Config().GetDuration(AuthServiceTimeoutKey),
+			MethodTimeouts:      loadDurationMap(AuthServiceMethodTimeoutsKey),
+			Retry: RetryConfig{
+				MaxAttempts:    app.Config().GetInt(AuthServiceRetryMaxAttemptsKey),
+				InitialBackoff: app.Config().GetDuration(AuthServiceRetryInitialBackoffKey),
+				MaxBackoff:     app.Config().GetDuration(AuthServiceRetryMaxBackoffKey),
+			},
+			CircuitBreaker: CircuitBreakerConfig{
+				FailureThreshold: app.Config().GetInt(AuthServiceBreakerThresholdKey),
+				OpenTimeout:      app.Config().GetDuration(AuthServiceBreakerOpenTimeoutKey),
+			},
+			TLS: TLSConfig{
+				Enabled:    app.Config().GetBool(AuthServiceTLSEnabledKey),
+				CAFile:     app.Config().GetString(AuthServiceTLSCAFileKey),
+				CertFile:   app.Config().GetString(AuthServiceTLSCertFileKey),
+				KeyFile:    app.Config().GetString(AuthServiceTLSKeyFileKey),
+				ServerName: app.Config().GetString(AuthServiceTLSServerNameKey),
+			},
 		},
 		Website: WebsiteConfig{
-			DistPath: app.Config().GetString(WebsiteDistPathKey),
+			DistPath:         app.Config().GetString(WebsiteDistPathKey),
+			ExcludedPrefixes: app.Config().GetStringSlice(WebsiteExcludedPrefixesKey),
+			AssetPrefixes:    app.Config().GetStringSlice(WebsiteAssetPrefixesKey),
+			Title:            app.Config().GetString(WebsiteTitleKey),
+			PublicURL:        app.Config().GetString(WebsitePublicURLKey),
+			LoginProviders:   app.Config().GetStringSlice(WebsiteLoginProvidersKey),
+			Features:         loadBoolMap(WebsiteFeaturesKey),
+			Description:      app.Config().GetString(WebsiteDescriptionKey),
+			Image:            app.Config().GetString(WebsiteImageKey),
+			DevProxyURL:      app.Config().GetString(WebsiteDevProxyURLKey),
+		},
+		Tracing: TracingConfig{
+			Exporter:    app.Config().GetString(TracingExporterKey),
+			Endpoint:    app.Config().GetString(TracingEndpointKey),
+			Insecure:    app.Config().GetBool(TracingInsecureKey),
+			SampleRatio: app.Config().GetFloat64(TracingSampleRatioKey),
+			ServiceName: app.Config().GetString(TracingServiceNameKey),
+		},
+		Metrics: MetricsConfig{
+			Enabled:       app.Config().GetBool(MetricsEnabledKey),
+			Path:          app.Config().GetString(MetricsPathKey),
+			ListenAddress: app.Config().GetString(MetricsListenAddressKey),
+			BearerToken:   app.Config().GetString(MetricsBearerTokenKey),
 		},
+		RateLimit: RateLimitConfig{
+			Store: app.Config().GetString(RateLimitStoreKey),
+			Redis: RedisConfig{
+				URLs:      app.Config().GetStringSlice(RateLimitRedisURLsKey),
+				Password:  app.Config().GetString(RateLimitRedisPasswordKey),
+				KeyPrefix: app.Config().GetString(RateLimitRedisKeyPrefixKey),
+			},
+			Rules: loadRateLimitRules(RateLimitRulesKey),
+		},
+		CORS: loadCORSConfig(),
+		Security: SecurityConfig{
+			ContentSecurityPolicy: app.Config().GetString(SecurityCSPKey),
+			CSPReportOnly:         app.Config().GetBool(SecurityCSPReportOnlyKey),
+			FrameOptions:          app.Config().GetString(SecurityFrameOptionsKey),
+			ReferrerPolicy:        app.Config().GetString(SecurityReferrerPolicyKey),
+			PermissionsPolicy:     app.Config().GetString(SecurityPermissionsPolicyKey),
+			ContentTypeNosniff:    app.Config().GetBool(SecurityContentTypeNosniffKey),
+		},
+		Compression: CompressionConfig{
+			Enabled:      app.Config().GetBool(CompressionEnabledKey),
+			MinSize:      loadByteSize(CompressionMinSizeKey),
+			ContentTypes: app.Config().GetStringSlice(CompressionContentTypesKey),
+		},
+		Debug: DebugConfig{
+			Enabled:       app.Config().GetBool(DebugEnabledKey),
+			ListenAddress: app.Config().GetString(DebugListenAddressKey),
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// loadDurationMap reads a table of durations such as method timeouts
+func loadDurationMap(key string) map[string]time.Duration {
+	result := make(map[string]time.Duration)
+	for _, name := range tableNames(key) {
+		if d, err := time.ParseDuration(app.Config().GetString(key + "." + name)); err == nil {
+			result[name] = d
+		}
+	}
+	return result
+}
+
+// loadByteSize reads a size such as "64KB" or "1MB", zero when invalid
+func loadByteSize(key string) int64 {
+	size, _ := bytes.Parse(app.Config().GetString(key))
+	return size
+}
+
+// loadByteSizeMap reads a table of sizes such as "64KB" or "1MB"
+func loadByteSizeMap(key string) map[string]int64 {
+	result := make(map[string]int64)
+	for _, name := range tableNames(key) {
+		if size, err := bytes.Parse(app.Config().GetString(key + "." + name)); err == nil {
+			result[name] = size
+		}
+	}
+	return result
+}
+
+// loadBoolMap reads a table of flags
+func loadBoolMap(key string) map[string]bool {
+	result := make(map[string]bool)
+	for _, name := range tableNames(key) {
+		result[name] = app.Config().GetBool(key + "." + name)
+	}
+	return result
+}
+
+// loadRateLimitRules reads a table of named rate limit rules
+func loadRateLimitRules(key string) map[string]RateLimitRule {
+	rules := make(map[string]RateLimitRule)
+	for _, name := range tableNames(key) {
+		rules[name] = RateLimitRule{
+			Requests: app.Config().GetInt(key + "." + name + ".requests"),
+			Window:   app.Config().GetDuration(key + "." + name + ".window"),
+		}
+	}
+	return rules
+}
+
+// tableNames returns the entry names of the table at key. Unlike the keys
+// of GetStringMap, they include entries that only have defaults.
+func tableNames(key string) []string {
+	prefix := key + "."
+	var names []string
+	for _, k := range app.Config().AllKeys() {
+		rest, ok := strings.CutPrefix(k, prefix)
+		if !ok {
+			continue
+		}
+		name, _, _ := strings.Cut(rest, ".")
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// loadCORSConfig reads the [cors] table. Group policies inherit every field
+// they do not set from the top-level policy.
+func loadCORSConfig() CORSConfig {
+	base := loadCORSPolicy(CORSKey, CORSPolicy{})
+	cfg := CORSConfig{
+		Policy: base,
+		Groups: make(map[string]CORSPolicy),
+	}
+	for _, name := range tableNames(CORSGroupsKey) {
+		groupKey := CORSGroupsKey + "." + name
+		group := loadCORSPolicy(groupKey, base)
+		group.PathPrefix = app.Config().GetString(groupKey + "." + corsPathPrefixKey)
+		cfg.Groups[name] = group
 	}
 	return cfg
 }
+
+// loadCORSPolicy reads the policy under key, keeping the fields of parent
+// that are not set
+func loadCORSPolicy(key string, parent CORSPolicy) CORSPolicy {
+	v := app.Config()
+	policy := parent
+	if k := key + "." + corsAllowOriginsKey; v.IsSet(k) {
+		policy.AllowOrigins = v.GetStringSlice(k)
+	}
+	if k := key + "." + corsAllowMethodsKey; v.IsSet(k) {
+		policy.AllowMethods = v.GetStringSlice(k)
+	}
+	if k := key + "." + corsAllowHeadersKey; v.IsSet(k) {
+		policy.AllowHeaders = v.GetStringSlice(k)
+	}
+	if k := key + "." + corsExposeHeadersKey; v.IsSet(k) {
+		policy.ExposeHeaders = v.GetStringSlice(k)
+	}
+	if k := key + "." + corsAllowCredentialsKey; v.IsSet(k) {
+		policy.AllowCredentials = v.GetBool(k)
+	}
+	if k := key + "." + corsMaxAgeKey; v.IsSet(k) {
+		policy.MaxAge = v.GetDuration(k)
+	}
+	return policy
+}
+
+// Reload re-reads the default and mode specific config files so that
+// subsequent Load calls observe changes made on disk
+func Reload() error {
+	v := app.Config()
+
+	v.SetConfigName(defaultConfigName)
+	if err := v.ReadInConfig(); err != nil && !isConfigNotFound(err) {
+		return err
+	}
+
+	mode := os.Getenv(modeEnv)
+	if mode == "" {
+		mode = defaultMode
+	}
+	v.SetConfigName(mode)
+	if err := v.MergeInConfig(); err != nil && !isConfigNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func isConfigNotFound(err error) bool {
+	var notFound viper.ConfigFileNotFoundError
+	return errors.As(err, &notFound)
+}
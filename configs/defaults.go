@@ -0,0 +1,125 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// defaults apply to keys missing from the config files, so that a partial
+// or missing default.toml still yields a working configuration. Sizes are
+// strings such as "64KB", like in the files.
+var defaults = map[string]any{
+	ServerPortKey:                     uint(8080),
+	ServerShutdownTimeoutKey:          30 * time.Second,
+	ServerPreStopDelayKey:             time.Duration(0),
+	ServerReadHeaderTimeoutKey:        10 * time.Second,
+	ServerReadTimeoutKey:              30 * time.Second,
+	ServerWriteTimeoutKey:             60 * time.Second,
+	ServerIdleTimeoutKey:              120 * time.Second,
+	ServerTLSEnabledKey:               false,
+	ServerTLSRedirectPortKey:          uint(0),
+	ServerACMEEnabledKey:              false,
+	ServerACMEDomainsKey:              []string{},
+	ServerACMECacheDirKey:             "./data/acme",
+	ServerHSTSEnabledKey:              false,
+	ServerHSTSMaxAgeKey:               365 * 24 * time.Hour,
+	LogLevelKey:                       "info",
+	LogFormatKey:                      "json",
+	AuthServiceAddressKey:             "localhost:50051",
+	AuthServiceAddressesKey:           []string{},
+	AuthServiceLoadBalancingKey:       LoadBalancingRoundRobin,
+	AuthServiceHealthCheckIntervalKey: 10 * time.Second,
+	AuthServiceHealthCheckTimeoutKey:  2 * time.Second,
+	AuthServiceTimeoutKey:             5 * time.Second,
+	AuthServiceRetryMaxAttemptsKey:    3,
+	AuthServiceRetryInitialBackoffKey: 100 * time.Millisecond,
+	AuthServiceRetryMaxBackoffKey:     time.Second,
+	AuthServiceBreakerThresholdKey:    5,
+	AuthServiceBreakerOpenTimeoutKey:  30 * time.Second,
+	AuthServiceTLSEnabledKey:          false,
+	WebsiteDistPathKey:                "./website/dist",
+	WebsiteExcludedPrefixesKey:        []string{"/api/", "/auth/", "/admin/", "/health"},
+	WebsiteAssetPrefixesKey:           []string{"/assets/"},
+	WebsiteTitleKey:                   "OJ Lab",
+	WebsiteLoginProvidersKey:          []string{"github"},
+	TracingExporterKey:                TracingExporterOff,
+	TracingEndpointKey:                "localhost:4317",
+	TracingInsecureKey:                true,
+	TracingSampleRatioKey:             1.0,
+	TracingServiceNameKey:             "reborn",
+	MetricsEnabledKey:                 true,
+	MetricsPathKey:                    "/metrics",
+	MetricsListenAddressKey:           ":9090",
+	RateLimitStoreKey:                 RateLimitStoreMemory,
+	RateLimitRedisURLsKey:             []string{"localhost:6379"},
+	RateLimitRedisKeyPrefixKey:        "reborn:ratelimit:",
+	CompressionEnabledKey:             true,
+	CompressionMinSizeKey:             "1KB",
+	CompressionContentTypesKey: []string{
+		"application/json", "application/problem+json", "application/manifest+json",
+		"image/svg+xml", "text/css", "text/html", "text/javascript", "text/plain", "text/xml",
+	},
+	DebugEnabledKey:               true,
+	SecurityCSPKey:                defaultContentSecurityPolicy,
+	SecurityPermissionsPolicyKey:  "camera=(), microphone=(), geolocation=(), payment=()",
+	SecurityFrameOptionsKey:       "DENY",
+	SecurityReferrerPolicyKey:     "strict-origin-when-cross-origin",
+	SecurityContentTypeNosniffKey: true,
+}
+
+// defaultContentSecurityPolicy only allows same-origin resources, and
+// scripts and styles carrying the request nonce
+const defaultContentSecurityPolicy = "default-src 'self'; " +
+	"script-src 'self' 'nonce-" + CSPNoncePlaceholder + "'; " +
+	"style-src 'self' 'nonce-" + CSPNoncePlaceholder + "'; " +
+	"img-src 'self' data: https:; font-src 'self' data:; connect-src 'self'; " +
+	"object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors 'none'"
+
+// defaultCORSPolicy is the top-level [cors] policy, keyed relative to it
+var defaultCORSPolicy = map[string]any{
+	corsAllowOriginsKey: []string{"*"},
+	corsAllowMethodsKey: []string{"GET", "HEAD", "PUT", "PATCH", "POST", "DELETE"},
+	corsAllowHeadersKey: []string{"Origin", "Content-Type", "Accept", "Authorization"},
+	corsExposeHeadersKey: []string{
+		"X-Request-Id", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining",
+		"RateLimit-Reset", "RateLimit-Policy",
+	},
+	corsAllowCredentialsKey: false,
+	corsMaxAgeKey:           10 * time.Minute,
+}
+
+// defaultBodyLimits are the body limits of the route groups missing from
+// [server.body_limits]
+var defaultBodyLimits = map[string]string{
+	RouteGroupDefault:    "64KB",
+	RouteGroupAuth:       "16KB",
+	RouteGroupAPI:        "1MB",
+	RouteGroupSubmission: "256KB",
+}
+
+// defaultRateLimitRules are the rules of the route groups missing from
+// [rate_limit.rules]
+var defaultRateLimitRules = map[string]RateLimitRule{
+	RouteGroupDefault:    {Requests: 20, Window: time.Second},
+	RouteGroupAuth:       {Requests: 10, Window: time.Minute},
+	RouteGroupAPI:        {Requests: 20, Window: time.Second},
+	RouteGroupSubmission: {Requests: 5, Window: time.Minute},
+}
+
+// setDefaults registers the defaults with v
+func setDefaults(v *viper.Viper) {
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+	for key, value := range defaultCORSPolicy {
+		v.SetDefault(CORSKey+"."+key, value)
+	}
+	for group, size := range defaultBodyLimits {
+		v.SetDefault(ServerBodyLimitsKey+"."+group, size)
+	}
+	for group, rule := range defaultRateLimitRules {
+		v.SetDefault(RateLimitRulesKey+"."+group+".requests", rule.Requests)
+		v.SetDefault(RateLimitRulesKey+"."+group+".window", rule.Window)
+	}
+}
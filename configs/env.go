@@ -0,0 +1,84 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix prefixes the environment variables overriding settings
+const EnvPrefix = "REBORN_"
+
+// fileEnvSuffix marks a variable naming a file that holds the value, such as
+// a mounted secret
+const fileEnvSuffix = "_FILE"
+
+// envReplacer maps a key to the rest of its variable name
+var envReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// EnvName returns the variable overriding key, e.g. REBORN_SERVER_PORT for
+// server.port
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(envReplacer.Replace(key))
+}
+
+// PrepareEnv applies the defaults and REBORN_ variables to the global viper
+// before app.Init, since go-webmods sets the logger up from log.level and
+// log.format as soon as it has read the config files. Variables override
+// those files, like they do once Load runs. Errors are left for Load to
+// report.
+func PrepareEnv() {
+	v := viper.GetViper()
+	setDefaults(v)
+	_ = applyEnv(v)
+}
+
+// applyEnv overrides the settings of v with their REBORN_ variables. Lists
+// are comma separated. Only known keys can be overridden, i.e. keys with a
+// default or present in a config file.
+func applyEnv(v *viper.Viper) error {
+	var errs []error
+	for _, key := range v.AllKeys() {
+		name := EnvName(key)
+		value, ok := os.LookupEnv(name)
+
+		if path, isSet := os.LookupEnv(name + fileEnvSuffix); isSet {
+			if ok {
+				errs = append(errs, fmt.Errorf("%s and %s are both set", name, name+fileEnvSuffix))
+				continue
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name+fileEnvSuffix, err))
+				continue
+			}
+			// Secret files usually end with a newline
+			value, ok = strings.TrimRight(string(data), "\r\n"), true
+		}
+
+		if ok {
+			v.Set(key, envValue(v.Get(key), value))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// envValue converts a variable to the type of the current value of its key
+func envValue(current any, value string) any {
+	switch current.(type) {
+	case []any, []string:
+		items := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	default:
+		// Scalars are converted by the typed getters
+		return value
+	}
+}
@@ -0,0 +1,263 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oj-lab/go-webmods/app"
+	"github.com/spf13/viper"
+)
+
+// initApp starts go-webmods on a fresh viper, reading configs/default.toml
+// with the given content from a temporary working directory
+func initApp(t *testing.T, defaultTOML string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	if defaultTOML != "" {
+		configDir := filepath.Join(dir, "configs")
+		if err := os.Mkdir(configDir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(configDir, "default.toml"), []byte(defaultTOML), 0o600); err != nil {
+			t.Fatalf("write default.toml: %v", err)
+		}
+	}
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	PrepareEnv()
+	app.Init(dir)
+}
+
+// writeSecret writes value to a temporary file and returns its path
+func writeSecret(t *testing.T, value string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte(value), 0o600); err != nil {
+		t.Fatalf("write secret: %v", err)
+	}
+	return path
+}
+
+func TestEnvName(t *testing.T) {
+	for key, want := range map[string]string{
+		ServerPortKey:                  "REBORN_SERVER_PORT",
+		AuthServiceRetryMaxAttemptsKey: "REBORN_AUTH_SERVICE_RETRY_MAX_ATTEMPTS",
+		"cors.groups.open-api.origins": "REBORN_CORS_GROUPS_OPEN_API_ORIGINS",
+	} {
+		if got := EnvName(key); got != want {
+			t.Errorf("EnvName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     map[string]string
+		check   func(t *testing.T, cfg Config)
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Server.Port != 8080 || cfg.AuthService.Timeout != 5*time.Second {
+					t.Errorf("port %d, timeout %v, want the defaults", cfg.Server.Port, cfg.AuthService.Timeout)
+				}
+			},
+		},
+		{
+			name: "config file overrides defaults",
+			file: "[server]\nport = 9000\n",
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Server.Port != 9000 {
+					t.Errorf("port %d, want 9000", cfg.Server.Port)
+				}
+			},
+		},
+		{
+			name: "variable overrides config file",
+			file: "[server]\nport = 9000\n",
+			env:  map[string]string{"REBORN_SERVER_PORT": "9100"},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Server.Port != 9100 {
+					t.Errorf("port %d, want 9100", cfg.Server.Port)
+				}
+			},
+		},
+		{
+			name: "comma separated list",
+			env:  map[string]string{"REBORN_AUTH_SERVICE_ADDRESSES": "a:1, b:2,,"},
+			check: func(t *testing.T, cfg Config) {
+				if want := []string{"a:1", "b:2"}; !slices.Equal(cfg.AuthService.Addresses, want) {
+					t.Errorf("addresses %q, want %q", cfg.AuthService.Addresses, want)
+				}
+			},
+		},
+		{
+			name: "table entry from a config file",
+			file: "[auth_service.method_timeouts]\nListUsers = \"1s\"\n",
+			env:  map[string]string{"REBORN_AUTH_SERVICE_METHOD_TIMEOUTS_LISTUSERS": "3s"},
+			check: func(t *testing.T, cfg Config) {
+				if got := cfg.AuthService.MethodTimeouts["listusers"]; got != 3*time.Second {
+					t.Errorf("ListUsers timeout %v, want 3s", got)
+				}
+			},
+		},
+		{
+			name: "unknown variable is ignored",
+			env: map[string]string{
+				"REBORN_NOT_A_SETTING": "1", "REBORN_SERVER_PORTS": "x",
+				// Without a default or a config file entry, the key is unknown
+				"REBORN_METRICS_BEARER_TOKEN": "s3cret",
+			},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Server.Port != 8080 || cfg.Metrics.BearerToken != "" {
+					t.Errorf("port %d, bearer token %q, want 8080 and none", cfg.Server.Port, cfg.Metrics.BearerToken)
+				}
+			},
+		},
+		{
+			name: "log settings reach go-webmods",
+			file: "[log]\nlevel = \"info\"\n",
+			env:  map[string]string{"REBORN_LOG_LEVEL": "debug", "REBORN_LOG_FORMAT": "plain-text"},
+			check: func(t *testing.T, cfg Config) {
+				if got := app.Config().GetString(LogLevelKey); got != "debug" {
+					t.Errorf("log.level %q before Load, want debug", got)
+				}
+			},
+		},
+		{
+			name:    "wrongly typed number",
+			env:     map[string]string{"REBORN_SERVER_PORT": "eighty"},
+			wantErr: ServerPortKey,
+		},
+		{
+			name:    "wrongly typed duration",
+			env:     map[string]string{"REBORN_AUTH_SERVICE_TIMEOUT": "soon"},
+			wantErr: AuthServiceTimeoutKey,
+		},
+		{
+			name:    "wrongly typed size",
+			env:     map[string]string{"REBORN_COMPRESSION_MIN_SIZE": "huge"},
+			wantErr: `compression.min_size: invalid size "huge"`,
+		},
+		{
+			name:    "wrongly typed table duration",
+			file:    "[auth_service.method_timeouts]\nListUsers = \"later\"\n",
+			wantErr: `auth_service.method_timeouts.listusers: invalid duration "later"`,
+		},
+		{
+			name:    "unsupported log level",
+			env:     map[string]string{"REBORN_LOG_LEVEL": "loud"},
+			wantErr: LogLevelKey,
+		},
+		{
+			name:    "invalid value",
+			env:     map[string]string{"REBORN_AUTH_SERVICE_LOAD_BALANCING": "random"},
+			wantErr: AuthServiceLoadBalancingKey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			initApp(t, tt.file)
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want one mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestLoadFileEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		setValue  bool
+		file      string
+		missing   bool
+		wantToken string
+		wantErr   string
+	}{
+		{name: "trailing newline is trimmed", file: "s3cret\n", wantToken: "s3cret"},
+		{name: "windows newline is trimmed", file: "s3cret\r\n", wantToken: "s3cret"},
+		{name: "inner whitespace is kept", file: " s3 cret", wantToken: " s3 cret"},
+		{name: "both variables set", value: "other", setValue: true, file: "s3cret",
+			wantErr: "REBORN_METRICS_BEARER_TOKEN and REBORN_METRICS_BEARER_TOKEN_FILE are both set"},
+		{name: "both set even when empty", setValue: true, file: "s3cret",
+			wantErr: "are both set"},
+		{name: "missing file", missing: true, wantErr: "REBORN_METRICS_BEARER_TOKEN_FILE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "missing")
+			if !tt.missing {
+				path = writeSecret(t, tt.file)
+			}
+			t.Setenv("REBORN_METRICS_BEARER_TOKEN_FILE", path)
+			if tt.setValue {
+				t.Setenv("REBORN_METRICS_BEARER_TOKEN", tt.value)
+			}
+			initApp(t, "[metrics]\nbearer_token = \"\"\n")
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want one mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+			if cfg.Metrics.BearerToken != tt.wantToken {
+				t.Errorf("bearer token %q, want %q", cfg.Metrics.BearerToken, tt.wantToken)
+			}
+		})
+	}
+}
+
+func TestEnvValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		current any
+		value   string
+		want    any
+	}{
+		{name: "string list", current: []string{"a"}, value: "b, c", want: []string{"b", "c"}},
+		{name: "list from a file", current: []any{"a"}, value: "b", want: []string{"b"}},
+		{name: "empty list", current: []string{"a"}, value: " , ", want: []string{}},
+		{name: "scalar is kept as text", current: 8080, value: "9090", want: "9090"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := envValue(tt.current, tt.value)
+			if list, ok := got.([]string); ok {
+				if want, _ := tt.want.([]string); !slices.Equal(list, want) {
+					t.Errorf("envValue = %q, want %q", got, tt.want)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("envValue = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
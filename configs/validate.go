@@ -0,0 +1,223 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/gommon/bytes"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// Supported values of the log settings read by go-webmods
+var (
+	logLevels  = []string{"debug", "info", "warn", "error"}
+	logFormats = []string{"json", "plain-text", "tint"}
+)
+
+// checkTypes reports settings that do not convert to the type of their
+// default, or to a size or duration in tables, which the typed getters would
+// silently turn into zero values
+func checkTypes(v *viper.Viper) error {
+	var errs []error
+	for key, def := range defaults {
+		var err error
+		switch def.(type) {
+		case time.Duration:
+			_, err = cast.ToDurationE(v.Get(key))
+		case uint:
+			_, err = cast.ToUintE(v.Get(key))
+		case int:
+			_, err = cast.ToIntE(v.Get(key))
+		case float64:
+			_, err = cast.ToFloat64E(v.Get(key))
+		case bool:
+			_, err = cast.ToBoolE(v.Get(key))
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+
+	sizeKeys := []string{CompressionMinSizeKey}
+	for _, name := range tableNames(ServerBodyLimitsKey) {
+		sizeKeys = append(sizeKeys, ServerBodyLimitsKey+"."+name)
+	}
+	for _, key := range sizeKeys {
+		if _, err := bytes.Parse(v.GetString(key)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid size %q", key, v.GetString(key)))
+		}
+	}
+
+	var durationKeys []string
+	for _, name := range tableNames(AuthServiceMethodTimeoutsKey) {
+		durationKeys = append(durationKeys, AuthServiceMethodTimeoutsKey+"."+name)
+	}
+	for _, name := range tableNames(RateLimitRulesKey) {
+		durationKeys = append(durationKeys, RateLimitRulesKey+"."+name+".window")
+	}
+	for _, key := range durationKeys {
+		if _, err := time.ParseDuration(v.GetString(key)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid duration %q", key, v.GetString(key)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate reports every invalid setting, naming its key
+func (c Config) Validate() error {
+	var errs []error
+	invalid := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+	nonNegative := func(key string, d time.Duration) {
+		if d < 0 {
+			invalid(key, "must not be negative")
+		}
+	}
+	positive := func(key string, d time.Duration) {
+		if d <= 0 {
+			invalid(key, "must be positive")
+		}
+	}
+	oneOf := func(key, value string, allowed ...string) {
+		if !slices.Contains(allowed, value) {
+			invalid(key, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+		}
+	}
+
+	// Server
+	server := c.Server
+	if server.Port == 0 || server.Port > 65535 {
+		invalid(ServerPortKey, "must be between 1 and 65535")
+	}
+	positive(ServerShutdownTimeoutKey, server.ShutdownTimeout)
+	nonNegative(ServerPreStopDelayKey, server.PreStopDelay)
+	nonNegative(ServerReadTimeoutKey, server.ReadTimeout)
+	nonNegative(ServerReadHeaderTimeoutKey, server.ReadHeaderTimeout)
+	nonNegative(ServerWriteTimeoutKey, server.WriteTimeout)
+	nonNegative(ServerIdleTimeoutKey, server.IdleTimeout)
+	for group, limit := range server.BodyLimits {
+		if limit <= 0 {
+			invalid(ServerBodyLimitsKey+"."+group, "must be positive")
+		}
+	}
+
+	tls := server.TLS
+	if tls.RedirectPort > 65535 || (tls.RedirectPort != 0 && tls.RedirectPort == server.Port) {
+		invalid(ServerTLSRedirectPortKey, "must be a free port between 1 and 65535, or 0")
+	}
+	switch {
+	case tls.ACME.Enabled && !tls.Enabled:
+		invalid(ServerACMEEnabledKey, "requires %s", ServerTLSEnabledKey)
+	case tls.ACME.Enabled:
+		if len(tls.ACME.Domains) == 0 {
+			invalid(ServerACMEDomainsKey, "required when ACME is enabled")
+		}
+		if tls.ACME.CacheDir == "" {
+			invalid(ServerACMECacheDirKey, "required when ACME is enabled")
+		}
+	case tls.Enabled:
+		if tls.CertFile == "" || tls.KeyFile == "" {
+			invalid(ServerTLSCertFileKey, "cert_file and key_file are required when TLS is enabled")
+		}
+	}
+	nonNegative(ServerHSTSMaxAgeKey, server.HSTS.MaxAge)
+
+	// Auth service
+	auth := c.AuthService
+	if auth.Address == "" && len(auth.Addresses) == 0 {
+		invalid(AuthServiceAddressKey, "required unless %s is set", AuthServiceAddressesKey)
+	}
+	oneOf(AuthServiceLoadBalancingKey, auth.LoadBalancing,
+		LoadBalancingPickFirst, LoadBalancingRoundRobin, LoadBalancingLeastRequest)
+	positive(AuthServiceHealthCheckIntervalKey, auth.HealthCheckInterval)
+	positive(AuthServiceHealthCheckTimeoutKey, auth.HealthCheckTimeout)
+	positive(AuthServiceTimeoutKey, auth.Timeout)
+	for method, timeout := range auth.MethodTimeouts {
+		positive(AuthServiceMethodTimeoutsKey+"."+method, timeout)
+	}
+	if auth.Retry.MaxAttempts < 0 {
+		invalid(AuthServiceRetryMaxAttemptsKey, "must not be negative")
+	}
+	nonNegative(AuthServiceRetryInitialBackoffKey, auth.Retry.InitialBackoff)
+	nonNegative(AuthServiceRetryMaxBackoffKey, auth.Retry.MaxBackoff)
+	if auth.CircuitBreaker.FailureThreshold < 0 {
+		invalid(AuthServiceBreakerThresholdKey, "must not be negative")
+	}
+	nonNegative(AuthServiceBreakerOpenTimeoutKey, auth.CircuitBreaker.OpenTimeout)
+	if (auth.TLS.CertFile == "") != (auth.TLS.KeyFile == "") {
+		invalid(AuthServiceTLSCertFileKey, "cert_file and key_file must be set together")
+	}
+
+	// Website
+	for key, value := range map[string]string{
+		WebsitePublicURLKey:   c.Website.PublicURL,
+		WebsiteDevProxyURLKey: c.Website.DevProxyURL,
+	} {
+		if value == "" {
+			continue
+		}
+		if u, err := url.Parse(value); err != nil ||
+			(u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid(key, "must be an absolute http(s) URL, got %q", value)
+		}
+	}
+
+	// Tracing
+	oneOf(TracingExporterKey, c.Tracing.Exporter,
+		TracingExporterOff, TracingExporterOTLP, TracingExporterStdout)
+	if c.Tracing.Exporter == TracingExporterOTLP && c.Tracing.Endpoint == "" {
+		invalid(TracingEndpointKey, "required for the otlp exporter")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		invalid(TracingSampleRatioKey, "must be between 0 and 1")
+	}
+
+	// Metrics
+	if c.Metrics.Enabled && !strings.HasPrefix(c.Metrics.Path, "/") {
+		invalid(MetricsPathKey, "must start with /")
+	}
+	if c.Metrics.Enabled && c.Metrics.ListenAddress == "" && c.Metrics.BearerToken == "" {
+		invalid(MetricsBearerTokenKey, "required when metrics are served on %s, unless %s is set",
+			ServerPortKey, MetricsListenAddressKey)
+	}
+
+	// Rate limiting
+	oneOf(RateLimitStoreKey, c.RateLimit.Store, RateLimitStoreMemory, RateLimitStoreRedis)
+	if c.RateLimit.Store == RateLimitStoreRedis && len(c.RateLimit.Redis.URLs) == 0 {
+		invalid(RateLimitRedisURLsKey, "required for the redis store")
+	}
+	for name, rule := range c.RateLimit.Rules {
+		if rule.Requests < 0 {
+			invalid(RateLimitRulesKey+"."+name+".requests", "must not be negative")
+		}
+		if rule.Requests > 0 && rule.Window <= 0 {
+			invalid(RateLimitRulesKey+"."+name+".window", "must be positive")
+		}
+	}
+
+	if c.Compression.MinSize < 0 {
+		invalid(CompressionMinSizeKey, "must not be negative")
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateLog checks the log settings, which go-webmods reads on its own
+func validateLog(v *viper.Viper) error {
+	var errs []error
+	if level := v.GetString(LogLevelKey); !slices.Contains(logLevels, level) {
+		errs = append(errs, fmt.Errorf("%s: must be one of %s, got %q",
+			LogLevelKey, strings.Join(logLevels, ", "), level))
+	}
+	if format := v.GetString(LogFormatKey); !slices.Contains(logFormats, format) {
+		errs = append(errs, fmt.Errorf("%s: must be one of %s, got %q",
+			LogFormatKey, strings.Join(logFormats, ", "), format))
+	}
+	return errors.Join(errs...)
+}
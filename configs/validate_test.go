@@ -0,0 +1,154 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// loadDefaults returns the configuration built from the defaults alone
+func loadDefaults(t *testing.T) Config {
+	t.Helper()
+
+	initApp(t, "")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr string
+	}{
+		{name: "defaults", modify: func(c *Config) {}},
+		{
+			name:    "port out of range",
+			modify:  func(c *Config) { c.Server.Port = 70000 },
+			wantErr: ServerPortKey,
+		},
+		{
+			name:    "negative pre-stop delay",
+			modify:  func(c *Config) { c.Server.PreStopDelay = -time.Second },
+			wantErr: ServerPreStopDelayKey + ": must not be negative",
+		},
+		{
+			name:    "redirect to the server port",
+			modify:  func(c *Config) { c.Server.TLS.RedirectPort = c.Server.Port },
+			wantErr: ServerTLSRedirectPortKey,
+		},
+		{
+			name:    "ACME without TLS",
+			modify:  func(c *Config) { c.Server.TLS.ACME.Enabled = true },
+			wantErr: ServerACMEEnabledKey + ": requires " + ServerTLSEnabledKey,
+		},
+		{
+			name: "ACME without domains",
+			modify: func(c *Config) {
+				c.Server.TLS.Enabled = true
+				c.Server.TLS.ACME.Enabled = true
+			},
+			wantErr: ServerACMEDomainsKey,
+		},
+		{
+			name:    "TLS without a certificate",
+			modify:  func(c *Config) { c.Server.TLS.Enabled = true },
+			wantErr: ServerTLSCertFileKey,
+		},
+		{
+			name: "no auth service address",
+			modify: func(c *Config) {
+				c.AuthService.Address = ""
+				c.AuthService.Addresses = nil
+			},
+			wantErr: AuthServiceAddressKey,
+		},
+		{
+			name:    "client certificate without its key",
+			modify:  func(c *Config) { c.AuthService.TLS.CertFile = "client.pem" },
+			wantErr: AuthServiceTLSCertFileKey,
+		},
+		{
+			name:    "relative public URL",
+			modify:  func(c *Config) { c.Website.PublicURL = "/oj" },
+			wantErr: WebsitePublicURLKey,
+		},
+		{
+			name:    "dev proxy URL without a scheme",
+			modify:  func(c *Config) { c.Website.DevProxyURL = "localhost:5173" },
+			wantErr: WebsiteDevProxyURLKey,
+		},
+		{
+			name:    "sample ratio above 1",
+			modify:  func(c *Config) { c.Tracing.SampleRatio = 1.5 },
+			wantErr: TracingSampleRatioKey,
+		},
+		{
+			name: "otlp without an endpoint",
+			modify: func(c *Config) {
+				c.Tracing.Exporter = TracingExporterOTLP
+				c.Tracing.Endpoint = ""
+			},
+			wantErr: TracingEndpointKey,
+		},
+		{
+			name:    "metrics on the server port without a token",
+			modify:  func(c *Config) { c.Metrics.ListenAddress = "" },
+			wantErr: MetricsBearerTokenKey,
+		},
+		{
+			name: "metrics on the server port with a token",
+			modify: func(c *Config) {
+				c.Metrics.ListenAddress = ""
+				c.Metrics.BearerToken = "s3cret"
+			},
+		},
+		{
+			name:    "redis store without URLs",
+			modify:  func(c *Config) { c.RateLimit.Store, c.RateLimit.Redis.URLs = RateLimitStoreRedis, nil },
+			wantErr: RateLimitRedisURLsKey,
+		},
+		{
+			name:    "rate limit rule without a window",
+			modify:  func(c *Config) { c.RateLimit.Rules = map[string]RateLimitRule{"api": {Requests: 10}} },
+			wantErr: RateLimitRulesKey + ".api.window",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := loadDefaults(t)
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryError(t *testing.T) {
+	cfg := loadDefaults(t)
+	cfg.Server.Port = 0
+	cfg.Tracing.SampleRatio = -1
+	cfg.RateLimit.Store = "memcached"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() succeeded")
+	}
+	for _, key := range []string{ServerPortKey, TracingSampleRatioKey, RateLimitStoreKey} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Validate() error %q does not mention %s", err, key)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Stable, machine-readable error codes. Clients may rely on these, so
+// existing values must never change meaning.
+const (
+	CodeBadRequest         = "bad_request"
+	CodeValidationFailed   = "validation_failed"
+	CodeUnauthenticated    = "unauthenticated"
+	CodeInvalidToken       = "invalid_token"
+	CodeForbidden          = "forbidden"
+	CodeAdminRequired      = "admin_required"
+	CodeNotFound           = "not_found"
+	CodeMethodNotAllowed   = "method_not_allowed"
+	CodeConflict           = "conflict"
+	CodePayloadTooLarge    = "payload_too_large"
+	CodeRateLimited        = "rate_limited"
+	CodeInternal           = "internal"
+	CodeNotImplemented     = "not_implemented"
+	CodeServiceUnavailable = "service_unavailable"
+)
+
+// Field validation codes used in FieldError.Code
+const (
+	FieldRequired   = "required"
+	FieldInvalid    = "invalid"
+	FieldOutOfRange = "out_of_range"
+)
+
+// TypePrefix prefixes the code to form the RFC 7807 problem type URI
+const TypePrefix = "urn:reborn:error:"
+
+// FieldError describes why a single request field was rejected. Params fill
+// the placeholders of the localized message for Code.
+type FieldError struct {
+	Field   string         `json:"field"`
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Params  map[string]any `json:"params,omitempty"`
+}
+
+// Error is an application error carrying everything needed to render a
+// problem response. Detail is shown to users, Internal never is.
+type Error struct {
+	Status   int
+	Code     string
+	Detail   string
+	Fields   []FieldError
+	Internal error
+}
+
+// New creates an application error with a user-facing detail message
+func New(status int, code, detail string) *Error {
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+// Validation creates a validation error for the given rejected fields
+func Validation(fields ...FieldError) *Error {
+	return &Error{
+		Status: http.StatusBadRequest,
+		Code:   CodeValidationFailed,
+		Detail: "Request validation failed",
+		Fields: fields,
+	}
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if e.Internal != nil {
+		return fmt.Sprintf("%s (%d): %s: %v", e.Code, e.Status, e.Detail, e.Internal)
+	}
+	return fmt.Sprintf("%s (%d): %s", e.Code, e.Status, e.Detail)
+}
+
+// Unwrap returns the internal cause of the error
+func (e *Error) Unwrap() error {
+	return e.Internal
+}
+
+// WithInternal records the underlying cause, for logs only
+func (e *Error) WithInternal(err error) *Error {
+	e.Internal = err
+	return e
+}
+
+// StatusOf returns the HTTP status an error will be rendered with
+func StatusOf(err error) int {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Status
+	}
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		return he.Code
+	}
+	return http.StatusInternalServerError
+}
+
+// CodeForStatus returns the default error code for an HTTP status
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return CodeUnauthenticated
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusNotImplemented:
+		return CodeNotImplemented
+	case http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return CodeServiceUnavailable
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
@@ -0,0 +1,41 @@
+package apperror
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FromGRPC maps an error returned by a gRPC call to an application error.
+// fallback is the detail shown when the status has no better description.
+func FromGRPC(err error, fallback string) *Error {
+	var appErr *Error
+	switch status.Code(err) {
+	case codes.OK:
+		return nil
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		appErr = New(http.StatusBadRequest, CodeBadRequest, "Invalid request")
+	case codes.Unauthenticated:
+		appErr = New(http.StatusUnauthorized, CodeInvalidToken, "Invalid or expired token")
+	case codes.PermissionDenied:
+		appErr = New(http.StatusForbidden, CodeForbidden, "Permission denied")
+	case codes.NotFound:
+		appErr = New(http.StatusNotFound, CodeNotFound, "Resource not found")
+	case codes.AlreadyExists, codes.Aborted:
+		appErr = New(http.StatusConflict, CodeConflict, "Resource conflict")
+	case codes.ResourceExhausted:
+		appErr = New(http.StatusTooManyRequests, CodeRateLimited, "Too many requests")
+	case codes.Unimplemented:
+		appErr = New(http.StatusNotImplemented, CodeNotImplemented, "Not implemented")
+	case codes.Unavailable, codes.DeadlineExceeded:
+		appErr = New(
+			http.StatusServiceUnavailable,
+			CodeServiceUnavailable,
+			"Service temporarily unavailable",
+		)
+	default:
+		appErr = New(http.StatusInternalServerError, CodeInternal, fallback)
+	}
+	return appErr.WithInternal(err)
+}
@@ -0,0 +1,134 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+
+	config "github.com/oj-lab/reborn/configs"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Manager provides the certificates of the HTTPS server, either from files
+// or obtained through ACME
+type Manager struct {
+	tlsConfig *tls.Config
+	autocert  *autocert.Manager
+	reloader  *fileReloader
+}
+
+// NewManager loads the certificates configured in cfg. ACME takes precedence
+// over certificate files.
+func NewManager(cfg config.ServerTLSConfig) (*Manager, error) {
+	if cfg.ACME.Enabled {
+		return newACMEManager(cfg.ACME)
+	}
+
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("tls requires cert_file and key_file, or acme to be enabled")
+	}
+	reloader, err := newFileReloader(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{
+		tlsConfig: &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			NextProtos:     []string{"h2", "http/1.1"},
+			GetCertificate: reloader.GetCertificate,
+		},
+		reloader: reloader,
+	}, nil
+}
+
+func newACMEManager(cfg config.ACMEConfig) (*Manager, error) {
+	if len(cfg.Domains) == 0 {
+		return nil, errors.New("acme requires at least one domain")
+	}
+	if cfg.CacheDir == "" {
+		return nil, errors.New("acme requires a cache_dir to keep certificates across restarts")
+	}
+
+	client := &acme.Client{DirectoryURL: cfg.DirectoryURL}
+	if cfg.CAFile != "" {
+		httpClient, err := httpClientTrusting(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		client.HTTPClient = httpClient
+	}
+
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(cfg.CacheDir),
+		HostPolicy: autocert.HostWhitelist(cfg.Domains...),
+		Email:      cfg.Email,
+		Client:     client,
+	}
+	tlsConfig := m.TLSConfig()
+	tlsConfig.MinVersion = tls.VersionTLS12
+	return &Manager{tlsConfig: tlsConfig, autocert: m}, nil
+}
+
+// TLSConfig returns the configuration for the HTTPS listener
+func (m *Manager) TLSConfig() *tls.Config {
+	return m.tlsConfig
+}
+
+// RedirectHandler redirects plain HTTP requests to HTTPS on httpsPort and
+// answers ACME HTTP-01 challenges
+func (m *Manager) RedirectHandler(httpsPort uint) http.Handler {
+	redirect := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.FormatUint(uint64(httpsPort), 10))
+		}
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	})
+
+	if m.autocert != nil {
+		return m.autocert.HTTPHandler(redirect)
+	}
+	return redirect
+}
+
+// Close stops watching certificate files
+func (m *Manager) Close() error {
+	if m.reloader != nil {
+		return m.reloader.Close()
+	}
+	return nil
+}
+
+// httpClientTrusting returns an HTTP client that trusts the certificates in
+// caFile in addition to the system roots
+func httpClientTrusting(caFile string) (*http.Client, error) {
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file %s: %w", caFile, err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in CA file %s", caFile)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    pool,
+	}
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,230 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	config "github.com/oj-lab/reborn/configs"
+)
+
+// writeSelfSigned writes a self-signed certificate for commonName and its key
+// as PEM files in dir
+func writeSelfSigned(t *testing.T, dir, commonName string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	certFile = filepath.Join(dir, "tls.crt")
+	keyFile = filepath.Join(dir, "tls.key")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+// writePEM replaces file atomically, like certbot or a secret update
+func writePEM(t *testing.T, file, blockType string, der []byte) {
+	t.Helper()
+
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write %s: %v", tmp, err)
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		t.Fatalf("rename %s: %v", tmp, err)
+	}
+}
+
+// servedCommonName returns the common name of the leaf the manager serves
+func servedCommonName(t *testing.T, m *Manager) string {
+	t.Helper()
+
+	cert, err := m.TLSConfig().GetCertificate(&tls.ClientHelloInfo{ServerName: "localhost"})
+	if err != nil {
+		t.Fatalf("GetCertificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("parse leaf: %v", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func newFileManager(t *testing.T, dir string) *Manager {
+	t.Helper()
+
+	certFile, keyFile := writeSelfSigned(t, dir, "first.test")
+	m, err := NewManager(config.ServerTLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	t.Cleanup(func() { _ = m.Close() })
+	return m
+}
+
+func TestManagerReloadsCertificateFiles(t *testing.T) {
+	dir := t.TempDir()
+	m := newFileManager(t, dir)
+
+	if got := servedCommonName(t, m); got != "first.test" {
+		t.Fatalf("serving %q, want first.test", got)
+	}
+
+	writeSelfSigned(t, dir, "second.test")
+	deadline := time.Now().Add(5 * time.Second)
+	for servedCommonName(t, m) != "second.test" {
+		if time.Now().After(deadline) {
+			t.Fatal("rewritten certificate was not picked up")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestManagerKeepsCertificateOnInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	m := newFileManager(t, dir)
+
+	if err := os.WriteFile(filepath.Join(dir, "tls.crt"), []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	time.Sleep(2 * reloadDelay)
+
+	if got := servedCommonName(t, m); got != "first.test" {
+		t.Errorf("serving %q after an invalid rewrite, want first.test", got)
+	}
+}
+
+func TestNewManagerRequiresCertificate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.ServerTLSConfig
+	}{
+		{name: "no files", cfg: config.ServerTLSConfig{Enabled: true}},
+		{name: "missing files", cfg: config.ServerTLSConfig{
+			Enabled:  true,
+			CertFile: filepath.Join(t.TempDir(), "missing.crt"),
+			KeyFile:  filepath.Join(t.TempDir(), "missing.key"),
+		}},
+		{name: "acme without domains", cfg: config.ServerTLSConfig{
+			Enabled: true,
+			ACME:    config.ACMEConfig{Enabled: true, CacheDir: t.TempDir()},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if m, err := NewManager(tt.cfg); err == nil {
+				_ = m.Close()
+				t.Error("NewManager succeeded")
+			}
+		})
+	}
+}
+
+func TestRedirectHandler(t *testing.T) {
+	fileManager := newFileManager(t, t.TempDir())
+	acmeManager, err := NewManager(config.ServerTLSConfig{
+		Enabled: true,
+		ACME: config.ACMEConfig{
+			Enabled:  true,
+			Domains:  []string{"example.com"},
+			CacheDir: t.TempDir(),
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewManager with ACME: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		manager      *Manager
+		httpsPort    uint
+		target       string
+		wantStatus   int
+		wantLocation string
+	}{
+		{
+			name:         "default port",
+			manager:      fileManager,
+			httpsPort:    443,
+			target:       "http://example.com/problems?page=2",
+			wantStatus:   http.StatusPermanentRedirect,
+			wantLocation: "https://example.com/problems?page=2",
+		},
+		{
+			name:         "custom port replaces the http port",
+			manager:      fileManager,
+			httpsPort:    8443,
+			target:       "http://example.com:8080/",
+			wantStatus:   http.StatusPermanentRedirect,
+			wantLocation: "https://example.com:8443/",
+		},
+		{
+			name:         "ipv6 host",
+			manager:      fileManager,
+			httpsPort:    8443,
+			target:       "http://[::1]:8080/login",
+			wantStatus:   http.StatusPermanentRedirect,
+			wantLocation: "https://[::1]:8443/login",
+		},
+		{
+			name:         "acme redirects other paths",
+			manager:      acmeManager,
+			httpsPort:    443,
+			target:       "http://example.com/",
+			wantStatus:   http.StatusPermanentRedirect,
+			wantLocation: "https://example.com/",
+		},
+		{
+			name:       "acme answers challenges",
+			manager:    acmeManager,
+			httpsPort:  443,
+			target:     "http://example.com/.well-known/acme-challenge/unknown-token",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			tt.manager.RedirectHandler(tt.httpsPort).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+		})
+	}
+}
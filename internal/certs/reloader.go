@@ -0,0 +1,150 @@
+package certs
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay coalesces the burst of events emitted when certificates are
+// replaced, e.g. by certbot or a Kubernetes secret update
+const reloadDelay = 500 * time.Millisecond
+
+// fileReloader serves a certificate from PEM files and loads it again
+// whenever they change, keeping the previous one if the new files are invalid
+type fileReloader struct {
+	certFile string
+	keyFile  string
+	watcher  *fsnotify.Watcher
+	done     chan struct{}
+
+	mu     sync.RWMutex
+	cert   *tls.Certificate
+	timer  *time.Timer
+	closed bool
+}
+
+func newFileReloader(certFile, keyFile string) (*fileReloader, error) {
+	r := &fileReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		done:     make(chan struct{}),
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// Watch the directories rather than the files to survive atomic renames
+	// and symlink swaps
+	for _, dir := range uniqueDirs(certFile, keyFile) {
+		if err := watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+			return nil, fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+	}
+	r.watcher = watcher
+	go r.run()
+	return r, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (r *fileReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+func (r *fileReloader) load() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate %s: %w", r.certFile, err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *fileReloader) run() {
+	defer close(r.done)
+
+	for {
+		select {
+		case _, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+			r.scheduleReload()
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Error("Certificate watcher error", "error", err)
+		}
+	}
+}
+
+func (r *fileReloader) scheduleReload() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return
+	}
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.timer = time.AfterFunc(reloadDelay, r.reload)
+}
+
+func (r *fileReloader) reload() {
+	r.mu.RLock()
+	closed := r.closed
+	r.mu.RUnlock()
+	if closed {
+		return
+	}
+
+	if err := r.load(); err != nil {
+		slog.Error("Failed to reload certificate, keeping current one", "error", err)
+		return
+	}
+	slog.Info("Certificate reloaded", "cert_file", r.certFile)
+}
+
+// Close stops watching and waits for the event loop to exit
+func (r *fileReloader) Close() error {
+	r.mu.Lock()
+	r.closed = true
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.mu.Unlock()
+
+	err := r.watcher.Close()
+	<-r.done
+	return err
+}
+
+func uniqueDirs(files ...string) []string {
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, file := range files {
+		dir := filepath.Dir(file)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
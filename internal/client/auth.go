@@ -1,54 +1,177 @@
 package client
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"os"
+	"sync/atomic"
 	"time"
 
 	config "github.com/oj-lab/reborn/configs"
+	"github.com/oj-lab/reborn/internal/metrics"
+	"github.com/oj-lab/reborn/internal/telemetry"
 	"github.com/oj-lab/user-service/pkg/userpb"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/keepalive"
 )
 
 // AuthServiceClient manages the gRPC client for auth service
 type AuthServiceClient struct {
-	client userpb.AuthServiceClient
-	conn   *grpc.ClientConn
-	config config.AuthServiceConfig
+	client   userpb.AuthServiceClient
+	conn     *grpc.ClientConn
+	config   config.AuthServiceConfig
+	breaker  *circuitBreaker
+	inflight *atomic.Int64
+	backends *backendProber
 }
 
 // NewAuthServiceClient creates a new auth service client with configuration
 func NewAuthServiceClient(cfg config.AuthServiceConfig) (*AuthServiceClient, error) {
+	breaker := newCircuitBreaker(cfg.CircuitBreaker)
+	inflight := &atomic.Int64{}
+
+	creds, err := transportCredentials(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
 	opts := []grpc.DialOption{
 		grpc.WithKeepaliveParams(keepalive.ClientParameters{
 			Time:                30 * time.Second, // Send pings every 30 seconds instead of 10
 			Timeout:             5 * time.Second,  // Wait 5 seconds for ping response
 			PermitWithoutStream: false,            // Only send pings when there are active streams
 		}),
+		// Per-method timeouts and retries for idempotent calls
+		grpc.WithDefaultServiceConfig(buildServiceConfig(cfg)),
+		grpc.WithChainUnaryInterceptor(
+			inflightInterceptor(inflight),
+			metrics.GRPCClientInterceptor,
+			telemetry.RequestIDUnaryClientInterceptor,
+			breaker.unaryInterceptor,
+		),
+		// Client spans and W3C traceparent propagation, without probe noise
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler(
+			otelgrpc.WithFilter(filters.Not(filters.HealthCheck())),
+		)),
+		grpc.WithTransportCredentials(creds),
 	}
 
-	opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
-
-	// Attempt connection with retries
-	var conn *grpc.ClientConn
-	var err error
+	target, resolverOpts := dialTarget(cfg)
+	opts = append(opts, resolverOpts...)
 
-	conn, err = grpc.NewClient(cfg.Address, opts...)
+	conn, err := grpc.NewClient(target, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to auth service at %s: %w", cfg.Address, err)
+		return nil, fmt.Errorf("failed to connect to auth service at %s: %w", target, err)
+	}
+
+	var backends *backendProber
+	if hasReplicas(cfg) {
+		backends = newBackendProber(cfg, creds)
 	}
 
 	client := userpb.NewAuthServiceClient(conn)
 	return &AuthServiceClient{
-		client: client,
-		conn:   conn,
-		config: cfg,
+		client:   client,
+		conn:     conn,
+		config:   cfg,
+		breaker:  breaker,
+		inflight: inflight,
+		backends: backends,
 	}, nil
 }
 
+// transportCredentials returns TLS credentials when enabled, plaintext otherwise
+func transportCredentials(cfg config.TLSConfig) (credentials.TransportCredentials, error) {
+	if !cfg.Enabled {
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.ServerName,
+	}
+
+	if cfg.CAFile != "" {
+		caPEM, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file %s: %w", cfg.CAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// inflightInterceptor counts calls that have not returned yet
+func inflightInterceptor(inflight *atomic.Int64) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		inflight.Add(1)
+		defer inflight.Add(-1)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// Drain waits until no calls are in flight on this client or ctx is done
+func (c *AuthServiceClient) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+
+	for c.inflight.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d calls still in flight: %w", c.inflight.Load(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// Config returns the configuration the client was created with
+func (c *AuthServiceClient) Config() config.AuthServiceConfig {
+	return c.config
+}
+
+// CheckBackends probes every replica when the client balances across
+// several of them, and returns nil otherwise
+func (c *AuthServiceClient) CheckBackends(ctx context.Context) ([]BackendHealth, error) {
+	if c.backends == nil {
+		return nil, nil
+	}
+	return c.backends.check(ctx)
+}
+
 // Close closes the gRPC connection
 func (c *AuthServiceClient) Close() error {
+	if c.backends != nil {
+		c.backends.close()
+	}
 	if c.conn != nil {
 		return c.conn.Close()
 	}
@@ -64,3 +187,29 @@ func (c *AuthServiceClient) GetClient() userpb.AuthServiceClient {
 func (c *AuthServiceClient) GetUserServiceClient() userpb.UserServiceClient {
 	return userpb.NewUserServiceClient(c.conn)
 }
+
+// State returns the connectivity state of the underlying gRPC connection
+func (c *AuthServiceClient) State() connectivity.State {
+	return c.conn.GetState()
+}
+
+// CircuitBreakerState returns "closed", "open" or "half_open"
+func (c *AuthServiceClient) CircuitBreakerState() string {
+	return c.breaker.State()
+}
+
+// Connect asks an idle connection to start connecting
+func (c *AuthServiceClient) Connect() {
+	c.conn.Connect()
+}
+
+// CheckHealth queries the standard grpc.health.v1 service of the auth service
+func (c *AuthServiceClient) CheckHealth(
+	ctx context.Context,
+) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	resp, err := healthpb.NewHealthClient(c.conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return healthpb.HealthCheckResponse_UNKNOWN, err
+	}
+	return resp.GetStatus(), nil
+}
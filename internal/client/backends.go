@@ -0,0 +1,196 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	config "github.com/oj-lab/reborn/configs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// staticScheme is served by a per-client resolver for Addresses
+	staticScheme = "static"
+	staticTarget = staticScheme + ":///auth-service"
+	dnsPrefix    = "dns:///"
+	// defaultGRPCPort matches the gRPC DNS resolver default
+	defaultGRPCPort = "443"
+)
+
+// BackendHealth is the result of probing a single auth service replica
+type BackendHealth struct {
+	Address string
+	Healthy bool
+	Latency time.Duration
+	Err     error
+}
+
+// dialTarget returns the target to dial, along with a static resolver when
+// a list of replica addresses is configured
+func dialTarget(cfg config.AuthServiceConfig) (string, []grpc.DialOption) {
+	if len(cfg.Addresses) == 0 {
+		return cfg.Address, nil
+	}
+
+	addresses := make([]resolver.Address, 0, len(cfg.Addresses))
+	for _, addr := range cfg.Addresses {
+		addresses = append(addresses, resolver.Address{Addr: addr})
+	}
+
+	r := manual.NewBuilderWithScheme(staticScheme)
+	r.InitialState(resolver.State{Addresses: addresses})
+	return staticTarget, []grpc.DialOption{grpc.WithResolvers(r)}
+}
+
+// hasReplicas reports whether cfg may resolve to more than one backend
+func hasReplicas(cfg config.AuthServiceConfig) bool {
+	return len(cfg.Addresses) > 0 || strings.HasPrefix(cfg.Address, dnsPrefix)
+}
+
+// backendAddresses lists the replicas currently behind the configured target
+func backendAddresses(ctx context.Context, cfg config.AuthServiceConfig) ([]string, error) {
+	if len(cfg.Addresses) > 0 {
+		return cfg.Addresses, nil
+	}
+	if !strings.HasPrefix(cfg.Address, dnsPrefix) {
+		return []string{cfg.Address}, nil
+	}
+
+	hostPort := strings.TrimPrefix(cfg.Address, dnsPrefix)
+	host, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		host, port = hostPort, defaultGRPCPort
+	}
+
+	ips, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+
+	addresses := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addresses = append(addresses, net.JoinHostPort(ip, port))
+	}
+	return addresses, nil
+}
+
+// backendProber keeps one health-only connection per replica, so that the
+// health of every backend can be reported even though calls are balanced
+type backendProber struct {
+	cfg   config.AuthServiceConfig
+	creds credentials.TransportCredentials
+
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn
+}
+
+func newBackendProber(
+	cfg config.AuthServiceConfig,
+	creds credentials.TransportCredentials,
+) *backendProber {
+	return &backendProber{
+		cfg:   cfg,
+		creds: creds,
+		conns: make(map[string]*grpc.ClientConn),
+	}
+}
+
+// check probes every replica concurrently
+func (p *backendProber) check(ctx context.Context) ([]BackendHealth, error) {
+	addresses, err := backendAddresses(ctx, p.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	conns, err := p.sync(addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BackendHealth, len(addresses))
+	var wg sync.WaitGroup
+	for i, addr := range addresses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = probeBackend(ctx, addr, conns[addr])
+		}()
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// sync opens connections for new replicas and closes those that went away
+func (p *backendProber) sync(addresses []string) (map[string]*grpc.ClientConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	wanted := make(map[string]bool, len(addresses))
+	for _, addr := range addresses {
+		wanted[addr] = true
+		if _, ok := p.conns[addr]; ok {
+			continue
+		}
+		conn, err := grpc.NewClient(
+			"passthrough:///"+addr,
+			grpc.WithTransportCredentials(p.creds),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create probe connection to %s: %w", addr, err)
+		}
+		p.conns[addr] = conn
+	}
+
+	for addr, conn := range p.conns {
+		if !wanted[addr] {
+			_ = conn.Close()
+			delete(p.conns, addr)
+		}
+	}
+
+	conns := make(map[string]*grpc.ClientConn, len(p.conns))
+	for addr, conn := range p.conns {
+		conns[addr] = conn
+	}
+	return conns, nil
+}
+
+// close closes all probe connections
+func (p *backendProber) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for addr, conn := range p.conns {
+		_ = conn.Close()
+		delete(p.conns, addr)
+	}
+}
+
+func probeBackend(ctx context.Context, addr string, conn *grpc.ClientConn) BackendHealth {
+	start := time.Now()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	result := BackendHealth{Address: addr, Latency: time.Since(start)}
+
+	switch {
+	case status.Code(err) == codes.Unimplemented:
+		// The replica answered, it just does not expose the health service
+		result.Healthy = true
+	case err != nil:
+		result.Err = err
+	case resp.GetStatus() != healthpb.HealthCheckResponse_SERVING:
+		result.Err = fmt.Errorf("serving status is %s", resp.GetStatus())
+	default:
+		result.Healthy = true
+	}
+	return result
+}
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	config "github.com/oj-lab/reborn/configs"
+	"github.com/oj-lab/user-service/pkg/userpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// replica is a loopback auth service backend counting the calls it serves
+type replica struct {
+	userpb.UnimplementedUserServiceServer
+
+	addr   string
+	server *grpc.Server
+	health *health.Server
+	calls  atomic.Int64
+}
+
+func (r *replica) GetUser(context.Context, *userpb.GetUserRequest) (*userpb.User, error) {
+	r.calls.Add(1)
+	return &userpb.User{}, nil
+}
+
+func startReplicas(t *testing.T, n int) []*replica {
+	t.Helper()
+
+	replicas := make([]*replica, n)
+	for i := range replicas {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		r := &replica{
+			addr:   lis.Addr().String(),
+			server: grpc.NewServer(),
+			health: health.NewServer(),
+		}
+		userpb.RegisterUserServiceServer(r.server, r)
+		healthpb.RegisterHealthServer(r.server, r.health)
+		go func() { _ = r.server.Serve(lis) }()
+		t.Cleanup(r.server.Stop)
+		replicas[i] = r
+	}
+	return replicas
+}
+
+func newReplicaClient(t *testing.T, replicas []*replica) *AuthServiceClient {
+	t.Helper()
+
+	cfg := config.AuthServiceConfig{
+		LoadBalancing: config.LoadBalancingRoundRobin,
+		Timeout:       2 * time.Second,
+	}
+	for _, r := range replicas {
+		cfg.Addresses = append(cfg.Addresses, r.addr)
+	}
+
+	c, err := NewAuthServiceClient(cfg)
+	if err != nil {
+		t.Fatalf("NewAuthServiceClient: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+// waitForAllReplicas calls until every replica served a call, since round
+// robin only picks replicas whose subchannel is ready
+func waitForAllReplicas(t *testing.T, c *AuthServiceClient, replicas []*replica) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := c.GetUserServiceClient().GetUser(t.Context(), &userpb.GetUserRequest{}); err != nil {
+			t.Fatalf("GetUser: %v", err)
+		}
+		ready := true
+		for _, r := range replicas {
+			ready = ready && r.calls.Load() > 0
+		}
+		if ready {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("not every replica received a call")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRoundRobinSpreadsCallsAcrossReplicas(t *testing.T) {
+	replicas := startReplicas(t, 3)
+	c := newReplicaClient(t, replicas)
+	waitForAllReplicas(t, c, replicas)
+
+	for _, r := range replicas {
+		r.calls.Store(0)
+	}
+	const calls = 30
+	for range calls {
+		if _, err := c.GetUserServiceClient().GetUser(t.Context(), &userpb.GetUserRequest{}); err != nil {
+			t.Fatalf("GetUser: %v", err)
+		}
+	}
+
+	for _, r := range replicas {
+		if got := r.calls.Load(); got != calls/int64(len(replicas)) {
+			t.Errorf("replica %s served %d calls, want %d", r.addr, got, calls/len(replicas))
+		}
+	}
+}
+
+func TestCheckBackendsReportsEachReplica(t *testing.T) {
+	replicas := startReplicas(t, 3)
+	c := newReplicaClient(t, replicas)
+	waitForAllReplicas(t, c, replicas)
+
+	replicas[1].server.Stop()
+	replicas[2].health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 2*time.Second)
+	defer cancel()
+	backends, err := c.CheckBackends(ctx)
+	if err != nil {
+		t.Fatalf("CheckBackends: %v", err)
+	}
+	if len(backends) != len(replicas) {
+		t.Fatalf("got %d backends, want %d", len(backends), len(replicas))
+	}
+
+	wantHealthy := []bool{true, false, false}
+	for i, backend := range backends {
+		if backend.Address != replicas[i].addr {
+			t.Errorf("backend %d: address %s, want %s", i, backend.Address, replicas[i].addr)
+		}
+		if backend.Healthy != wantHealthy[i] {
+			t.Errorf("backend %s: healthy %v, want %v", backend.Address, backend.Healthy, wantHealthy[i])
+		}
+		if !backend.Healthy && backend.Err == nil {
+			t.Errorf("backend %s: unhealthy without an error", backend.Address)
+		}
+	}
+
+	// The balancer routes around the stopped and the unhealthy replica
+	// once it noticed them, failing fast meanwhile
+	replicas[0].calls.Store(0)
+	replicas[2].calls.Store(0)
+	deadline := time.Now().Add(5 * time.Second)
+	for served := 0; served < 10; {
+		_, err := c.GetUserServiceClient().GetUser(t.Context(), &userpb.GetUserRequest{})
+		switch {
+		case err == nil:
+			served++
+		case time.Now().After(deadline):
+			t.Fatalf("GetUser with one healthy replica: %v", err)
+		default:
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	if got := replicas[0].calls.Load(); got != 10 {
+		t.Errorf("healthy replica served %d calls, want 10", got)
+	}
+}
@@ -0,0 +1,184 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	config "github.com/oj-lab/reborn/configs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultBreakerFailureThreshold = 5
+	defaultBreakerOpenTimeout      = 30 * time.Second
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitOpenError is returned when the circuit breaker rejects a call
+// without sending it to the downstream service
+type CircuitOpenError struct {
+	retryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker is open, retry after %s", e.retryAfter)
+}
+
+// GRPCStatus lets status.FromError treat the rejection as Unavailable
+func (e *CircuitOpenError) GRPCStatus() *status.Status {
+	return status.New(codes.Unavailable, e.Error())
+}
+
+// RetryAfter returns how long callers should wait before trying again
+func (e *CircuitOpenError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// circuitBreaker opens after consecutive downstream failures and lets a
+// single trial call through once the open timeout has elapsed
+type circuitBreaker struct {
+	threshold   int
+	openTimeout time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+func newCircuitBreaker(cfg config.CircuitBreakerConfig) *circuitBreaker {
+	b := &circuitBreaker{
+		threshold:   cfg.FailureThreshold,
+		openTimeout: cfg.OpenTimeout,
+	}
+	if b.threshold <= 0 {
+		b.threshold = defaultBreakerFailureThreshold
+	}
+	if b.openTimeout <= 0 {
+		b.openTimeout = defaultBreakerOpenTimeout
+	}
+	return b
+}
+
+// allow reports whether a call may proceed
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if elapsed := time.Since(b.openedAt); elapsed < b.openTimeout {
+			return &CircuitOpenError{retryAfter: b.openTimeout - elapsed}
+		}
+		b.state = breakerHalfOpen
+		b.trial = true
+		return nil
+	case breakerHalfOpen:
+		if b.trial {
+			return &CircuitOpenError{retryAfter: time.Second}
+		}
+		b.trial = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// record updates the breaker with the outcome of a call
+func (b *circuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !isBreakerFailure(err) {
+		b.state = breakerClosed
+		b.failures = 0
+		b.trial = false
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+		b.trial = false
+	}
+}
+
+// skip ends a call without recording its outcome, letting another trial
+// call through when the breaker is half-open
+func (b *circuitBreaker) skip() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+// State returns the current breaker state as a string
+func (b *circuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state.String()
+}
+
+// unaryInterceptor guards every call except health checks, which must keep
+// reporting the real state of the downstream service
+func (b *circuitBreaker) unaryInterceptor(
+	ctx context.Context,
+	method string,
+	req, reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	if method == healthpb.Health_Check_FullMethodName {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	if err := b.allow(); err != nil {
+		return err
+	}
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	if ctx.Err() != nil {
+		// The caller canceled or ran out of time, which says nothing about
+		// the downstream service
+		b.skip()
+		return err
+	}
+	b.record(err)
+	return err
+}
+
+// isBreakerFailure reports whether err indicates the downstream service is
+// unhealthy, as opposed to a regular application error
+func isBreakerFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted,
+		codes.Internal, codes.Unknown:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	config "github.com/oj-lab/reborn/configs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	errUnavailable = status.Error(codes.Unavailable, "connection refused")
+	errNotFound    = status.Error(codes.NotFound, "user not found")
+)
+
+const testMethod = "/UserService/GetUser"
+
+// call sends a call through the breaker interceptor, failing with err
+func call(b *circuitBreaker, ctx context.Context, method string, err error) (invoked bool, _ error) {
+	invoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		invoked = true
+		return err
+	}
+	return invoked, b.unaryInterceptor(ctx, method, nil, nil, nil, invoker)
+}
+
+// expireOpenTimeout makes an open breaker eligible for a trial call
+func expireOpenTimeout(b *circuitBreaker) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.openedAt = time.Now().Add(-b.openTimeout)
+}
+
+func assertState(t *testing.T, b *circuitBreaker, want string) {
+	t.Helper()
+	if got := b.State(); got != want {
+		t.Fatalf("state = %s, want %s", got, want)
+	}
+}
+
+func TestCircuitBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	b := newCircuitBreaker(config.CircuitBreakerConfig{FailureThreshold: 3, OpenTimeout: time.Minute})
+
+	// Application errors and successes reset the count
+	for _, err := range []error{errUnavailable, errUnavailable, errNotFound, errUnavailable, nil} {
+		_, _ = call(b, t.Context(), testMethod, err)
+	}
+	assertState(t, b, "closed")
+
+	for range 3 {
+		_, _ = call(b, t.Context(), testMethod, errUnavailable)
+	}
+	assertState(t, b, "open")
+
+	invoked, err := call(b, t.Context(), testMethod, nil)
+	var openErr *CircuitOpenError
+	if invoked || !errors.As(err, &openErr) {
+		t.Fatalf("open breaker invoked %v, error %v, want a CircuitOpenError", invoked, err)
+	}
+	if openErr.RetryAfter() <= 0 || openErr.RetryAfter() > time.Minute {
+		t.Errorf("retry after %v, want within the open timeout", openErr.RetryAfter())
+	}
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("code = %s, want Unavailable", status.Code(err))
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name      string
+		trialErr  error
+		wantState string
+	}{
+		{name: "successful trial closes", trialErr: nil, wantState: "closed"},
+		{name: "application error closes", trialErr: errNotFound, wantState: "closed"},
+		{name: "failed trial reopens", trialErr: errUnavailable, wantState: "open"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newCircuitBreaker(config.CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute})
+			_, _ = call(b, t.Context(), testMethod, errUnavailable)
+			assertState(t, b, "open")
+			expireOpenTimeout(b)
+
+			// Only one trial call at a time is let through
+			if err := b.allow(); err != nil {
+				t.Fatalf("trial call rejected: %v", err)
+			}
+			assertState(t, b, "half_open")
+			if invoked, _ := call(b, t.Context(), testMethod, nil); invoked {
+				t.Fatal("second call invoked during the trial")
+			}
+
+			b.record(tt.trialErr)
+			assertState(t, b, tt.wantState)
+		})
+	}
+}
+
+func TestCircuitBreakerSkipsCallerErrors(t *testing.T) {
+	canceled, cancel := context.WithCancel(t.Context())
+	cancel()
+	expired, cancelExpired := context.WithDeadline(t.Context(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	for name, ctx := range map[string]context.Context{"canceled": canceled, "deadline exceeded": expired} {
+		t.Run(name, func(t *testing.T) {
+			b := newCircuitBreaker(config.CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute})
+			_, _ = call(b, ctx, testMethod, status.FromContextError(ctx.Err()).Err())
+			assertState(t, b, "closed")
+
+			// A trial abandoned by its caller lets the next call through
+			_, _ = call(b, t.Context(), testMethod, errUnavailable)
+			expireOpenTimeout(b)
+			_, _ = call(b, ctx, testMethod, status.FromContextError(ctx.Err()).Err())
+			assertState(t, b, "half_open")
+			if invoked, err := call(b, t.Context(), testMethod, nil); !invoked || err != nil {
+				t.Fatalf("next trial invoked %v, error %v", invoked, err)
+			}
+			assertState(t, b, "closed")
+		})
+	}
+}
+
+func TestCircuitBreakerLetsHealthChecksThrough(t *testing.T) {
+	b := newCircuitBreaker(config.CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute})
+	_, _ = call(b, t.Context(), testMethod, errUnavailable)
+
+	if invoked, _ := call(b, t.Context(), healthpb.Health_Check_FullMethodName, nil); !invoked {
+		t.Error("health check rejected by an open breaker")
+	}
+	assertState(t, b, "open")
+}
@@ -0,0 +1,167 @@
+package client
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	config "github.com/oj-lab/reborn/configs"
+	"google.golang.org/grpc/balancer/leastrequest"
+	"google.golang.org/grpc/balancer/roundrobin"
+	_ "google.golang.org/grpc/health" // enables client-side health checking
+)
+
+const (
+	defaultCallTimeout       = 5 * time.Second
+	defaultRetryMaxAttempts  = 3
+	defaultRetryInitialDelay = 100 * time.Millisecond
+	defaultRetryMaxDelay     = time.Second
+	// gRPC caps retry attempts at 5 regardless of the service config
+	maxRetryAttempts = 5
+)
+
+// grpcMethod identifies a gRPC method in a service config
+type grpcMethod struct {
+	Service string `json:"service,omitempty"`
+	Method  string `json:"method,omitempty"`
+}
+
+// idempotentMethods are safe to retry because they have no side effects
+var idempotentMethods = []grpcMethod{
+	{Service: "AuthService", Method: "GetUserToken"},
+	{Service: "UserService", Method: "GetCurrentUser"},
+	{Service: "UserService", Method: "ListUsers"},
+}
+
+// knownMethods lists every method that may receive a timeout override
+var knownMethods = []grpcMethod{
+	{Service: "AuthService", Method: "GetOAuthCodeURL"},
+	{Service: "AuthService", Method: "LoginByOAuth"},
+	{Service: "AuthService", Method: "LoginByPassword"},
+	{Service: "AuthService", Method: "GetUserToken"},
+	{Service: "UserService", Method: "CreateUser"},
+	{Service: "UserService", Method: "GetCurrentUser"},
+	{Service: "UserService", Method: "GetUser"},
+	{Service: "UserService", Method: "ListUsers"},
+	{Service: "UserService", Method: "UpdateUser"},
+	{Service: "UserService", Method: "DeleteUser"},
+}
+
+type retryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+type methodConfig struct {
+	Name        []grpcMethod `json:"name"`
+	Timeout     string       `json:"timeout,omitempty"`
+	RetryPolicy *retryPolicy `json:"retryPolicy,omitempty"`
+}
+
+type healthCheckConfig struct {
+	ServiceName string `json:"serviceName"`
+}
+
+type serviceConfig struct {
+	LoadBalancingConfig []map[string]any   `json:"loadBalancingConfig,omitempty"`
+	HealthCheckConfig   *healthCheckConfig `json:"healthCheckConfig,omitempty"`
+	MethodConfig        []methodConfig     `json:"methodConfig"`
+}
+
+// buildServiceConfig renders the load balancing policy, per-method timeouts
+// and the retry policy for idempotent calls as a gRPC service config
+func buildServiceConfig(cfg config.AuthServiceConfig) string {
+	defaultTimeout := cfg.Timeout
+	if defaultTimeout <= 0 {
+		defaultTimeout = defaultCallTimeout
+	}
+
+	retry := newRetryPolicy(cfg.Retry)
+
+	// Config keys are case-insensitive, so match method names the same way
+	timeouts := make(map[string]time.Duration, len(cfg.MethodTimeouts))
+	for name, timeout := range cfg.MethodTimeouts {
+		timeouts[strings.ToLower(name)] = timeout
+	}
+
+	var sc serviceConfig
+	switch cfg.LoadBalancing {
+	case config.LoadBalancingRoundRobin:
+		sc.LoadBalancingConfig = []map[string]any{{roundrobin.Name: struct{}{}}}
+	case config.LoadBalancingLeastRequest:
+		sc.LoadBalancingConfig = []map[string]any{{leastrequest.Name: struct{}{}}}
+	}
+	if sc.LoadBalancingConfig != nil {
+		// Let the balancer skip replicas whose health service reports
+		// NOT_SERVING; servers without a health service count as healthy
+		sc.HealthCheckConfig = &healthCheckConfig{}
+	}
+
+	for _, method := range knownMethods {
+		timeout, overridden := timeouts[strings.ToLower(method.Method)]
+		if !overridden || timeout <= 0 {
+			timeout = defaultTimeout
+		}
+
+		mc := methodConfig{
+			Name:    []grpcMethod{method},
+			Timeout: formatDuration(timeout),
+		}
+		if retry != nil && slices.Contains(idempotentMethods, method) {
+			mc.RetryPolicy = retry
+		}
+		sc.MethodConfig = append(sc.MethodConfig, mc)
+	}
+
+	// An empty name matches every other method, e.g. health checks
+	sc.MethodConfig = append(sc.MethodConfig, methodConfig{
+		Name:    []grpcMethod{{}},
+		Timeout: formatDuration(defaultTimeout),
+	})
+
+	out, err := json.Marshal(sc)
+	if err != nil {
+		// All fields are plain values, so marshaling cannot fail
+		panic(err)
+	}
+	return string(out)
+}
+
+// newRetryPolicy returns nil when retries are disabled
+func newRetryPolicy(cfg config.RetryConfig) *retryPolicy {
+	attempts := cfg.MaxAttempts
+	if attempts == 0 {
+		attempts = defaultRetryMaxAttempts
+	}
+	if attempts <= 1 {
+		return nil
+	}
+	attempts = min(attempts, maxRetryAttempts)
+
+	initial := cfg.InitialBackoff
+	if initial <= 0 {
+		initial = defaultRetryInitialDelay
+	}
+	maxDelay := cfg.MaxBackoff
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+
+	return &retryPolicy{
+		MaxAttempts:          attempts,
+		InitialBackoff:       formatDuration(initial),
+		MaxBackoff:           formatDuration(max(initial, maxDelay)),
+		BackoffMultiplier:    2,
+		RetryableStatusCodes: []string{"UNAVAILABLE"},
+	}
+}
+
+// formatDuration renders d in the protobuf JSON duration format, e.g. "0.1s"
+func formatDuration(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
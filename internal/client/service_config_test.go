@@ -0,0 +1,112 @@
+package client
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	config "github.com/oj-lab/reborn/configs"
+)
+
+// parseServiceConfig builds the service config for cfg and decodes it
+func parseServiceConfig(t *testing.T, cfg config.AuthServiceConfig) serviceConfig {
+	t.Helper()
+
+	var sc serviceConfig
+	if err := json.Unmarshal([]byte(buildServiceConfig(cfg)), &sc); err != nil {
+		t.Fatalf("decode service config: %v", err)
+	}
+	return sc
+}
+
+// methodConfigFor returns the method config naming method, or the catch-all
+// one for an empty method
+func methodConfigFor(t *testing.T, sc serviceConfig, method grpcMethod) methodConfig {
+	t.Helper()
+
+	for _, mc := range sc.MethodConfig {
+		if len(mc.Name) == 1 && mc.Name[0] == method {
+			return mc
+		}
+	}
+	t.Fatalf("no method config for %+v", method)
+	return methodConfig{}
+}
+
+func TestBuildServiceConfigTimeouts(t *testing.T) {
+	sc := parseServiceConfig(t, config.AuthServiceConfig{
+		Timeout: 3 * time.Second,
+		// Keys arrive lowercased from the config files
+		MethodTimeouts: map[string]time.Duration{"listusers": 1500 * time.Millisecond, "getuser": 0},
+	})
+
+	if got := len(sc.MethodConfig); got != len(knownMethods)+1 {
+		t.Errorf("%d method configs, want one per known method and a catch-all", got)
+	}
+	for method, want := range map[grpcMethod]string{
+		{Service: "UserService", Method: "ListUsers"}:    "1.5s",
+		{Service: "UserService", Method: "GetUser"}:      "3s",
+		{Service: "AuthService", Method: "LoginByOAuth"}: "3s",
+		{}: "3s",
+	} {
+		if got := methodConfigFor(t, sc, method).Timeout; got != want {
+			t.Errorf("%+v timeout = %q, want %q", method, got, want)
+		}
+	}
+
+	if got := methodConfigFor(t, parseServiceConfig(t, config.AuthServiceConfig{}), grpcMethod{}).Timeout; got != "5s" {
+		t.Errorf("default timeout = %q, want 5s", got)
+	}
+}
+
+func TestBuildServiceConfigRetries(t *testing.T) {
+	sc := parseServiceConfig(t, config.AuthServiceConfig{Retry: config.RetryConfig{
+		MaxAttempts:    9,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+	}})
+
+	for _, mc := range sc.MethodConfig {
+		idempotent := len(mc.Name) == 1 && slices.Contains(idempotentMethods, mc.Name[0])
+		if idempotent != (mc.RetryPolicy != nil) {
+			t.Errorf("%+v retry policy %+v, want one only for idempotent methods", mc.Name, mc.RetryPolicy)
+		}
+	}
+	retry := methodConfigFor(t, sc, grpcMethod{Service: "UserService", Method: "ListUsers"}).RetryPolicy
+	if retry.MaxAttempts != maxRetryAttempts || retry.InitialBackoff != "0.2s" || retry.MaxBackoff != "0.2s" {
+		t.Errorf("retry policy %+v, want attempts capped at 5 and max backoff raised to the initial one", retry)
+	}
+
+	for _, attempts := range []int{1, -1} {
+		sc := parseServiceConfig(t, config.AuthServiceConfig{Retry: config.RetryConfig{MaxAttempts: attempts}})
+		for _, mc := range sc.MethodConfig {
+			if mc.RetryPolicy != nil {
+				t.Errorf("max_attempts %d: %+v retries, want none", attempts, mc.Name)
+			}
+		}
+	}
+}
+
+func TestBuildServiceConfigLoadBalancing(t *testing.T) {
+	for policy, want := range map[string]string{
+		config.LoadBalancingPickFirst:    "",
+		config.LoadBalancingRoundRobin:   "round_robin",
+		config.LoadBalancingLeastRequest: "least_request_experimental",
+	} {
+		sc := parseServiceConfig(t, config.AuthServiceConfig{LoadBalancing: policy})
+		if want == "" {
+			if sc.LoadBalancingConfig != nil || sc.HealthCheckConfig != nil {
+				t.Errorf("%s: balancing %v, health check %v, want neither",
+					policy, sc.LoadBalancingConfig, sc.HealthCheckConfig)
+			}
+			continue
+		}
+		if len(sc.LoadBalancingConfig) != 1 || sc.LoadBalancingConfig[0][want] == nil {
+			t.Errorf("%s: balancing %v, want %s", policy, sc.LoadBalancingConfig, want)
+		}
+		if sc.HealthCheckConfig == nil {
+			t.Errorf("%s: no client-side health checking", policy)
+		}
+	}
+}
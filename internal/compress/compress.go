@@ -0,0 +1,79 @@
+package compress
+
+import (
+	"compress/gzip"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Supported content codings, in order of preference
+const (
+	Brotli = "br"
+	Gzip   = "gzip"
+)
+
+// brotliLevel trades some ratio for speed, as responses are compressed on
+// every request
+const brotliLevel = 5
+
+// Encodings lists the supported content codings, preferred first
+var Encodings = []string{Brotli, Gzip}
+
+// Extensions maps content codings to the suffix of precompressed files
+var Extensions = map[string]string{
+	Brotli: ".br",
+	Gzip:   ".gz",
+}
+
+// Negotiate picks the first of offered that the Accept-Encoding header
+// allows, or an empty string for the identity coding
+func Negotiate(acceptEncoding string, offered ...string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok &&
+			strings.TrimSpace(name) == "q" {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		accepted[strings.ToLower(strings.TrimSpace(coding))] = q
+	}
+
+	for _, coding := range offered {
+		q, ok := accepted[coding]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > 0 {
+			return coding
+		}
+	}
+	return ""
+}
+
+// Writer is a compressing writer that can flush buffered data
+type Writer interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// NewWriter returns a writer compressing to w with the given content coding
+// at a level suited to responses compressed on the fly
+func NewWriter(coding string, w io.Writer) Writer {
+	if coding == Brotli {
+		return brotli.NewWriterLevel(w, brotliLevel)
+	}
+	gz, _ := gzip.NewWriterLevel(w, gzip.DefaultCompression)
+	return gz
+}
@@ -0,0 +1,72 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{acceptEncoding: "", want: ""},
+		{acceptEncoding: "identity", want: ""},
+		{acceptEncoding: "gzip", want: Gzip},
+		{acceptEncoding: "gzip, deflate, br", want: Brotli},
+		// The server preference wins over the client one
+		{acceptEncoding: "br;q=0.5, gzip;q=1", want: Brotli},
+		{acceptEncoding: "br;q=0, gzip", want: Gzip},
+		{acceptEncoding: "BR", want: Brotli},
+		{acceptEncoding: " gzip ; q=0.8 ", want: Gzip},
+		{acceptEncoding: "*", want: Brotli},
+		{acceptEncoding: "*;q=0, gzip", want: Gzip},
+		{acceptEncoding: "br;q=0, *", want: Gzip},
+		{acceptEncoding: "br;q=abc, gzip", want: Gzip},
+		{acceptEncoding: "gzip;q=0, br;q=0", want: ""},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.acceptEncoding, Encodings...); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.acceptEncoding, got, tt.want)
+		}
+	}
+
+	if got := Negotiate("br, gzip", Gzip); got != Gzip {
+		t.Errorf("Negotiate offering gzip only = %q, want gzip", got)
+	}
+}
+
+func TestNewWriter(t *testing.T) {
+	want := strings.Repeat("compress me ", 100)
+	for _, coding := range Encodings {
+		var buf bytes.Buffer
+		w := NewWriter(coding, &buf)
+		if _, err := io.WriteString(w, want); err != nil {
+			t.Fatalf("%s: write: %v", coding, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: close: %v", coding, err)
+		}
+		if buf.Len() >= len(want) {
+			t.Errorf("%s: %d bytes compressed to %d", coding, len(want), buf.Len())
+		}
+
+		var r io.Reader = brotli.NewReader(&buf)
+		if coding == Gzip {
+			gz, err := gzip.NewReader(&buf)
+			if err != nil {
+				t.Fatalf("gzip: %v", err)
+			}
+			r = gz
+		}
+		got, err := io.ReadAll(r)
+		if err != nil || string(got) != want {
+			t.Errorf("%s: round trip = %q, %v", coding, got, err)
+		}
+	}
+}
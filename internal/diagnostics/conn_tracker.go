@@ -0,0 +1,75 @@
+package diagnostics
+
+import (
+	"net"
+	"net/http"
+	"sync"
+)
+
+// ConnStats counts the connections of an HTTP server by state
+type ConnStats struct {
+	// Open is the number of connections currently open, new ones included
+	Open     int `json:"open"`
+	Active   int `json:"active"`
+	Idle     int `json:"idle"`
+	Hijacked int `json:"hijacked_total"`
+	Accepted int `json:"accepted_total"`
+	Closed   int `json:"closed_total"`
+}
+
+// ConnTracker follows the connections of an HTTP server through its
+// ConnState hook
+type ConnTracker struct {
+	mu     sync.Mutex
+	states map[net.Conn]http.ConnState
+	stats  ConnStats
+}
+
+// NewConnTracker creates an empty connection tracker
+func NewConnTracker() *ConnTracker {
+	return &ConnTracker{states: make(map[net.Conn]http.ConnState)}
+}
+
+// ConnState implements http.Server.ConnState
+func (t *ConnTracker) ConnState(conn net.Conn, state http.ConnState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if prev, ok := t.states[conn]; ok {
+		t.count(prev, -1)
+	}
+
+	switch state {
+	case http.StateNew:
+		t.stats.Accepted++
+	case http.StateHijacked:
+		t.stats.Hijacked++
+		delete(t.states, conn)
+		return
+	case http.StateClosed:
+		t.stats.Closed++
+		delete(t.states, conn)
+		return
+	}
+	t.states[conn] = state
+	t.count(state, 1)
+}
+
+// Stats returns the current connection counts
+func (t *ConnTracker) Stats() ConnStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := t.stats
+	stats.Open = len(t.states)
+	return stats
+}
+
+func (t *ConnTracker) count(state http.ConnState, delta int) {
+	switch state {
+	case http.StateActive:
+		t.stats.Active += delta
+	case http.StateIdle:
+		t.stats.Idle += delta
+	}
+}
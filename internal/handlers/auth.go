@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/oj-lab/reborn/internal/apperror"
 	"github.com/oj-lab/reborn/internal/middlewares"
 	"github.com/oj-lab/reborn/internal/services"
 	"github.com/oj-lab/user-service/pkg/userpb"
@@ -28,7 +29,11 @@ func NewAuthHandler(authService *services.AuthService) *AuthHandler {
 func (h *AuthHandler) Login(ctx echo.Context) error {
 	// Check if auth service is available
 	if !h.authService.IsHealthy() {
-		return echo.NewHTTPError(http.StatusServiceUnavailable, "Auth service unavailable")
+		return apperror.New(
+			http.StatusServiceUnavailable,
+			apperror.CodeServiceUnavailable,
+			"Auth service unavailable",
+		)
 	}
 
 	// Get provider from query parameter, default to github
@@ -50,7 +55,8 @@ func (h *AuthHandler) Login(ctx echo.Context) error {
 			RedirectUrl: &redirectURL,
 		})
 	if err != nil {
-		slog.Error(
+		slog.ErrorContext(
+			ctx.Request().Context(),
 			"Failed to get OAuth URL",
 			"error",
 			err,
@@ -59,7 +65,7 @@ func (h *AuthHandler) Login(ctx echo.Context) error {
 			"redirect_url",
 			redirectURL,
 		)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get OAuth URL")
+		return apperror.FromGRPC(err, "Failed to get OAuth URL")
 	}
 
 	return ctx.Redirect(http.StatusFound, resp.GetUrl())
@@ -68,12 +74,26 @@ func (h *AuthHandler) Login(ctx echo.Context) error {
 // Callback handles OAuth callback requests
 func (h *AuthHandler) Callback(ctx echo.Context) error {
 	code := ctx.QueryParam("code")
+	state := ctx.QueryParam("state")
+	var fieldErrs []apperror.FieldError
 	if code == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, "Missing code parameter")
+		fieldErrs = append(fieldErrs, apperror.FieldError{
+			Field:   "code",
+			Code:    apperror.FieldRequired,
+			Message: "Missing code parameter",
+			Params:  map[string]any{"field": "code"},
+		})
 	}
-	state := ctx.QueryParam("state")
 	if state == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, "Missing state parameter")
+		fieldErrs = append(fieldErrs, apperror.FieldError{
+			Field:   "state",
+			Code:    apperror.FieldRequired,
+			Message: "Missing state parameter",
+			Params:  map[string]any{"field": "state"},
+		})
+	}
+	if len(fieldErrs) > 0 {
+		return apperror.Validation(fieldErrs...)
 	}
 
 	client := h.authService.GetClient()
@@ -83,8 +103,8 @@ func (h *AuthHandler) Callback(ctx echo.Context) error {
 			State: state,
 		})
 	if err != nil {
-		slog.Error("Failed to login by OAuth", "error", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to login by OAuth")
+		slog.ErrorContext(ctx.Request().Context(), "Failed to login by OAuth", "error", err)
+		return apperror.FromGRPC(err, "Failed to login by OAuth")
 	}
 	ctx.SetCookie(&http.Cookie{
 		Name:   middlewares.LoginSessionCookieName,
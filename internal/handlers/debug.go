@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/labstack/echo/v4"
+	"github.com/oj-lab/reborn/internal/diagnostics"
+	"github.com/oj-lab/reborn/internal/logging"
+	"github.com/oj-lab/reborn/internal/services"
+	"github.com/oj-lab/reborn/internal/version"
+)
+
+// goroutineDumpDebug selects full stack traces in the goroutine profile,
+// in the same format as an unrecovered panic
+const goroutineDumpDebug = 2
+
+// DebugHandler serves runtime diagnostics of the running process
+type DebugHandler struct {
+	serviceManager *services.ServiceManager
+	connTracker    *diagnostics.ConnTracker
+	startedAt      time.Time
+}
+
+// NewDebugHandler creates a new debug handler instance
+func NewDebugHandler(
+	serviceManager *services.ServiceManager,
+	connTracker *diagnostics.ConnTracker,
+) *DebugHandler {
+	return &DebugHandler{
+		serviceManager: serviceManager,
+		connTracker:    connTracker,
+		startedAt:      time.Now(),
+	}
+}
+
+// Goroutines dumps the stack traces of all goroutines as plain text
+func (h *DebugHandler) Goroutines(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
+	c.Response().WriteHeader(http.StatusOK)
+	return pprof.Lookup("goroutine").WriteTo(c.Response(), goroutineDumpDebug)
+}
+
+// Config returns the validated configuration the services run with, i.e.
+// the last one loaded, with secrets redacted
+func (h *DebugHandler) Config(c echo.Context) error {
+	settings := settingsOf(reflect.ValueOf(h.serviceManager.Config())).(map[string]any)
+	return c.JSON(http.StatusOK, redactSettings(settings))
+}
+
+// Build returns build information and runtime details of the process
+func (h *DebugHandler) Build(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]any{
+		"build": version.Get(),
+		"runtime": map[string]any{
+			"os":         runtime.GOOS,
+			"arch":       runtime.GOARCH,
+			"num_cpu":    runtime.NumCPU(),
+			"gomaxprocs": runtime.GOMAXPROCS(0),
+			"goroutines": runtime.NumGoroutine(),
+			"started_at": h.startedAt,
+			"uptime":     time.Since(h.startedAt).Round(time.Second).String(),
+		},
+	})
+}
+
+// Connections returns live connection counts of the main HTTP server
+func (h *DebugHandler) Connections(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.connTracker.Stats())
+}
+
+// settingsOf converts a configuration value to settings with snake_case keys
+// like in the config files, rendering durations as strings such as "5s"
+func settingsOf(v reflect.Value) any {
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		settings := make(map[string]any, v.NumField())
+		for i := range v.NumField() {
+			if field := v.Type().Field(i); field.IsExported() {
+				settings[snakeCase(field.Name)] = settingsOf(v.Field(i))
+			}
+		}
+		return settings
+	case reflect.Map:
+		settings := make(map[string]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			settings[fmt.Sprint(iter.Key().Interface())] = settingsOf(iter.Value())
+		}
+		return settings
+	case reflect.Slice:
+		items := make([]any, v.Len())
+		for i := range items {
+			items[i] = settingsOf(v.Index(i))
+		}
+		return items
+	default:
+		return v.Interface()
+	}
+}
+
+// snakeCase converts a Go field name to a config key, keeping acronyms
+// together: CSPReportOnly becomes csp_report_only and URLs becomes urls
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			// An acronym ends before the capital that starts the next word,
+			// unless only a plural s follows
+			nextStartsWord := i+1 < len(runes) && unicode.IsLower(runes[i+1]) &&
+				(runes[i+1] != 's' || i+2 < len(runes))
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				(unicode.IsUpper(prev) && nextStartsWord) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// redactSettings replaces the values of sensitive keys at any depth, unless
+// they are empty so that unset secrets remain visible
+func redactSettings(settings map[string]any) map[string]any {
+	redacted := make(map[string]any, len(settings))
+	for key, value := range settings {
+		switch nested, isMap := value.(map[string]any); {
+		case logging.IsSensitiveKey(key) && value != "":
+			redacted[key] = logging.RedactedValue
+		case isMap:
+			redacted[key] = redactSettings(nested)
+		default:
+			redacted[key] = value
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/oj-lab/reborn/internal/services"
+	"github.com/oj-lab/reborn/internal/version"
+)
+
+// Health check statuses
+const (
+	healthStatusOK       = "ok"
+	healthStatusReady    = "ready"
+	healthStatusNotReady = "not_ready"
+	healthStatusDraining = "draining"
+)
+
+// HealthHandler serves liveness, readiness and health detail checks
+type HealthHandler struct {
+	serviceManager *services.ServiceManager
+}
+
+// NewHealthHandler creates a new health handler instance
+func NewHealthHandler(serviceManager *services.ServiceManager) *HealthHandler {
+	return &HealthHandler{
+		serviceManager: serviceManager,
+	}
+}
+
+// Live reports that the process is up and serving HTTP
+func (h *HealthHandler) Live(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]string{"status": healthStatusOK})
+}
+
+// Ready reports whether the required services are healthy, with 503 when
+// they are not or shutdown has begun
+func (h *HealthHandler) Ready(c echo.Context) error {
+	ready, services := h.serviceManager.Ready()
+
+	status, code := healthStatusReady, http.StatusOK
+	switch {
+	case h.serviceManager.Draining():
+		status, code = healthStatusDraining, http.StatusServiceUnavailable
+	case !ready:
+		status, code = healthStatusNotReady, http.StatusServiceUnavailable
+	}
+	return c.JSON(code, map[string]any{
+		"status":   status,
+		"services": services,
+	})
+}
+
+// Details lists the health of every service, including latency, errors and
+// the server version where the service reports one
+func (h *HealthHandler) Details(c echo.Context) error {
+	ready, _ := h.serviceManager.Ready()
+	return c.JSON(http.StatusOK, map[string]any{
+		"ready":    ready,
+		"draining": h.serviceManager.Draining(),
+		"build":    version.Get(),
+		"services": h.serviceManager.HealthCheck(),
+	})
+}
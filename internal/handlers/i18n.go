@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/oj-lab/reborn/internal/apperror"
+	"github.com/oj-lab/reborn/internal/i18n"
+)
+
+// I18nHandler serves the server-side translation catalogs
+type I18nHandler struct{}
+
+// NewI18nHandler creates a new i18n handler instance
+func NewI18nHandler() *I18nHandler {
+	return &I18nHandler{}
+}
+
+// GetMessages returns the translations of a language
+//
+//	@Summary		Get translations
+//	@Description	Retrieve the server message catalog of a language, e.g. for error codes. Regional variants resolve to the closest supported language.
+//	@Tags			i18n
+//	@Produce		json
+//	@Param			lang	path		string	true	"Language code, e.g. en-US or zh-CN"
+//	@Success		200		{object}	map[string]any
+//	@Failure		404		{object}	middlewares.Problem	"Not Found"
+//	@Router			/i18n/{lang} [get]
+func (h *I18nHandler) GetMessages(c echo.Context) error {
+	lang, ok := i18n.Match(c.Param("lang"))
+	if !ok {
+		return apperror.New(http.StatusNotFound, apperror.CodeNotFound, "Language not found")
+	}
+	// Every supported language has a catalog
+	messages, _ := i18n.Messages(lang)
+
+	c.Response().Header().Set("Content-Language", lang)
+	c.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=3600")
+	return c.JSONBlob(http.StatusOK, messages)
+}
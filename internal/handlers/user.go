@@ -1,19 +1,27 @@
 package handlers
 
 import (
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 
 	"github.com/labstack/echo/v4"
+	"github.com/oj-lab/reborn/internal/apperror"
 	"github.com/oj-lab/reborn/internal/middlewares"
 	"github.com/oj-lab/reborn/internal/services"
 	"github.com/oj-lab/user-service/pkg/userpb"
-	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
+// Pagination defaults and limits for ListUsers
+const (
+	defaultPage     = 1
+	defaultPageSize = 10
+	maxPageSize     = 100
+)
+
 // UserHandler handles user-related HTTP requests
 type UserHandler struct {
 	authService *services.AuthService
@@ -34,30 +42,43 @@ func NewUserHandler(authService *services.AuthService) *UserHandler {
 //	@Accept			json
 //	@Produce		json
 //	@Success		200	{object}	userpb.User
-//	@Failure		401	{object}	echo.HTTPError	"Unauthorized"
-//	@Failure		500	{object}	echo.HTTPError	"Internal Server Error"
+//	@Failure		401	{object}	middlewares.Problem	"Unauthorized"
+//	@Failure		500	{object}	middlewares.Problem	"Internal Server Error"
+//	@Failure		503	{object}	middlewares.Problem	"Service Unavailable"
 //	@Router			/user/me [get]
 func (h *UserHandler) GetCurrentUser(c echo.Context) error {
 	// Check if user is authenticated
 	if !middlewares.IsAuthenticated(c) {
-		return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+		return apperror.New(
+			http.StatusUnauthorized,
+			apperror.CodeUnauthenticated,
+			"Authentication required",
+		)
 	}
 
 	// Get user token from context
 	userToken := middlewares.GetUserToken(c)
 	if userToken == "" {
-		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid user token")
+		return apperror.New(http.StatusUnauthorized, apperror.CodeInvalidToken, "Invalid user token")
 	}
 
 	// Check if auth service is available
 	if h.authService == nil || !h.authService.IsHealthy() {
-		return echo.NewHTTPError(http.StatusServiceUnavailable, "User service unavailable")
+		return apperror.New(
+			http.StatusServiceUnavailable,
+			apperror.CodeServiceUnavailable,
+			"User service unavailable",
+		)
 	}
 
 	// Get auth service client
 	authClient := h.authService.GetClient()
 	if authClient == nil {
-		return echo.NewHTTPError(http.StatusServiceUnavailable, "User service client unavailable")
+		return apperror.New(
+			http.StatusServiceUnavailable,
+			apperror.CodeServiceUnavailable,
+			"User service client unavailable",
+		)
 	}
 
 	// Create user service client using the same connection
@@ -68,23 +89,7 @@ func (h *UserHandler) GetCurrentUser(c echo.Context) error {
 	// Get current user information
 	user, err := userServiceClient.GetCurrentUser(ctx, &emptypb.Empty{})
 	if err != nil {
-		// Handle gRPC errors
-		if grpcStatus, ok := status.FromError(err); ok {
-			switch grpcStatus.Code() {
-			case codes.Unauthenticated:
-				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid or expired token")
-			case codes.NotFound:
-				return echo.NewHTTPError(http.StatusNotFound, "User not found")
-			case codes.PermissionDenied:
-				return echo.NewHTTPError(http.StatusForbidden, "Permission denied")
-			default:
-				return echo.NewHTTPError(
-					http.StatusInternalServerError,
-					"Failed to get user information",
-				)
-			}
-		}
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get user information")
+		return apperror.FromGRPC(err, "Failed to get user information")
 	}
 
 	return c.JSON(http.StatusOK, user)
@@ -98,35 +103,48 @@ func (h *UserHandler) GetCurrentUser(c echo.Context) error {
 //	@Accept			json
 //	@Produce		json
 //	@Param			page		query		int	false	"Page number (default: 1)"
-//	@Param			page_size	query		int	false	"Page size (default: 10)"
+//	@Param			page_size	query		int	false	"Page size (default: 10, max: 100)"
 //	@Success		200			{object}	userpb.ListUsersResponse
-//	@Failure		400			{object}	echo.HTTPError	"Bad Request"
-//	@Failure		401			{object}	echo.HTTPError	"Unauthorized"
-//	@Failure		403			{object}	echo.HTTPError	"Forbidden - Admin access required"
-//	@Failure		500			{object}	echo.HTTPError	"Internal Server Error"
+//	@Failure		400			{object}	middlewares.Problem	"Bad Request"
+//	@Failure		401			{object}	middlewares.Problem	"Unauthorized"
+//	@Failure		403			{object}	middlewares.Problem	"Forbidden - Admin access required"
+//	@Failure		500			{object}	middlewares.Problem	"Internal Server Error"
+//	@Failure		503			{object}	middlewares.Problem	"Service Unavailable"
 //	@Router			/user/list [get]
 //	@Security		BearerAuth
 func (h *UserHandler) ListUsers(c echo.Context) error {
 	// Check if user is authenticated
 	if !middlewares.IsAuthenticated(c) {
-		return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+		return apperror.New(
+			http.StatusUnauthorized,
+			apperror.CodeUnauthenticated,
+			"Authentication required",
+		)
 	}
 
 	// Get user token from context
 	userToken := middlewares.GetUserToken(c)
 	if userToken == "" {
-		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid user token")
+		return apperror.New(http.StatusUnauthorized, apperror.CodeInvalidToken, "Invalid user token")
 	}
 
 	// Check if auth service is available
 	if h.authService == nil || !h.authService.IsHealthy() {
-		return echo.NewHTTPError(http.StatusServiceUnavailable, "User service unavailable")
+		return apperror.New(
+			http.StatusServiceUnavailable,
+			apperror.CodeServiceUnavailable,
+			"User service unavailable",
+		)
 	}
 
 	// Get auth service client
 	authClient := h.authService.GetClient()
 	if authClient == nil {
-		return echo.NewHTTPError(http.StatusServiceUnavailable, "User service client unavailable")
+		return apperror.New(
+			http.StatusServiceUnavailable,
+			apperror.CodeServiceUnavailable,
+			"User service client unavailable",
+		)
 	}
 
 	// Create user service client using the same connection
@@ -135,33 +153,59 @@ func (h *UserHandler) ListUsers(c echo.Context) error {
 	md := metadata.Pairs("authorization", "Bearer "+userToken)
 	ctx := metadata.NewOutgoingContext(c.Request().Context(), md)
 
-	page := c.QueryParam("page")
-	pageSize := c.QueryParam("page_size")
-
-	if page == "" {
-		page = "1"
+	var fieldErrs []apperror.FieldError
+	pageInt, fieldErr := parseIntParam(c, "page", defaultPage, 1, math.MaxUint32)
+	if fieldErr != nil {
+		fieldErrs = append(fieldErrs, *fieldErr)
 	}
-	if pageSize == "" {
-		pageSize = "10"
+	pageSizeInt, fieldErr := parseIntParam(c, "page_size", defaultPageSize, 1, maxPageSize)
+	if fieldErr != nil {
+		fieldErrs = append(fieldErrs, *fieldErr)
 	}
-
-	pageInt, err := strconv.ParseUint(page, 10, 32)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid page parameter")
-	}
-	pageSizeInt, err := strconv.ParseUint(pageSize, 10, 32)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid page size parameter")
+	if len(fieldErrs) > 0 {
+		return apperror.Validation(fieldErrs...)
 	}
 
 	// Get current user information
 	users, err := userServiceClient.ListUsers(ctx, &userpb.ListUsersRequest{
-		Page:     uint64(pageInt),
-		PageSize: uint64(pageSizeInt),
+		Page:     pageInt,
+		PageSize: pageSizeInt,
 	})
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list users")
+		return apperror.FromGRPC(err, "Failed to list users")
 	}
 
 	return c.JSON(http.StatusOK, users)
 }
+
+// parseIntParam reads an optional unsigned integer query parameter and checks
+// that it lies within [minValue, maxValue]
+func parseIntParam(
+	c echo.Context,
+	name string,
+	defaultValue, minValue, maxValue uint64,
+) (uint64, *apperror.FieldError) {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, &apperror.FieldError{
+			Field:   name,
+			Code:    apperror.FieldInvalid,
+			Message: fmt.Sprintf("%s must be a positive integer", name),
+			Params:  map[string]any{"field": name},
+		}
+	}
+	if value < minValue || value > maxValue {
+		return 0, &apperror.FieldError{
+			Field:   name,
+			Code:    apperror.FieldOutOfRange,
+			Message: fmt.Sprintf("%s must be between %d and %d", name, minValue, maxValue),
+			Params:  map[string]any{"field": name, "min": minValue, "max": maxValue},
+		}
+	}
+	return value, nil
+}
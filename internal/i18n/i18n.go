@@ -0,0 +1,135 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLanguage is used when no supported language is requested
+const DefaultLanguage = "en-US"
+
+// supportedLanguages lists the catalogs in locales, default first. Codes
+// match the ones used by the website so both can share translations.
+var supportedLanguages = []string{DefaultLanguage, "zh-CN"}
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// catalog holds the translations of a single language
+type catalog struct {
+	raw      json.RawMessage
+	messages map[string]string
+}
+
+var (
+	catalogs = mustLoadCatalogs()
+	matcher  = newMatcher()
+)
+
+// Negotiate picks the supported language that best matches the given
+// preferences, in order, falling back to DefaultLanguage
+func Negotiate(preferences ...string) string {
+	for _, pref := range preferences {
+		if lang, ok := Match(pref); ok {
+			return lang
+		}
+	}
+	return DefaultLanguage
+}
+
+// Match returns the supported language closest to pref, which may be a single
+// language code or a full Accept-Language header value
+func Match(pref string) (string, bool) {
+	if pref == "" {
+		return "", false
+	}
+	tags, _, err := language.ParseAcceptLanguage(pref)
+	if err != nil || len(tags) == 0 {
+		return "", false
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return "", false
+	}
+	return supportedLanguages[index], true
+}
+
+// Translate returns the message for key in lang, e.g. "errors.not_found",
+// with {{name}} placeholders replaced by params
+func Translate(lang, key string, params map[string]any) (string, bool) {
+	c, ok := catalogs[lang]
+	if !ok {
+		return "", false
+	}
+	msg, ok := c.messages[key]
+	if !ok {
+		return "", false
+	}
+
+	if len(params) > 0 {
+		oldnew := make([]string, 0, len(params)*2)
+		for name, value := range params {
+			oldnew = append(oldnew, "{{"+name+"}}", fmt.Sprint(value))
+		}
+		msg = strings.NewReplacer(oldnew...).Replace(msg)
+	}
+	return msg, true
+}
+
+// Messages returns the catalog of lang as nested JSON, in the resource
+// format expected by i18next
+func Messages(lang string) (json.RawMessage, bool) {
+	c, ok := catalogs[lang]
+	if !ok {
+		return nil, false
+	}
+	return c.raw, true
+}
+
+func newMatcher() language.Matcher {
+	tags := make([]language.Tag, 0, len(supportedLanguages))
+	for _, lang := range supportedLanguages {
+		tags = append(tags, language.MustParse(lang))
+	}
+	return language.NewMatcher(tags)
+}
+
+func mustLoadCatalogs() map[string]catalog {
+	catalogs := make(map[string]catalog, len(supportedLanguages))
+	for _, lang := range supportedLanguages {
+		raw, err := localeFS.ReadFile(path.Join("locales", lang+".json"))
+		if err != nil {
+			panic(fmt.Sprintf("missing catalog for %s: %v", lang, err))
+		}
+
+		var tree map[string]any
+		if err := json.Unmarshal(raw, &tree); err != nil {
+			panic(fmt.Sprintf("invalid catalog for %s: %v", lang, err))
+		}
+
+		messages := make(map[string]string)
+		flatten("", tree, messages)
+		catalogs[lang] = catalog{raw: raw, messages: messages}
+	}
+	return catalogs
+}
+
+// flatten turns nested catalog objects into dot-separated keys
+func flatten(prefix string, tree map[string]any, out map[string]string) {
+	for key, value := range tree {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case string:
+			out[key] = v
+		case map[string]any:
+			flatten(key, v, out)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package i18n
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name        string
+		preferences []string
+		want        string
+	}{
+		{name: "nothing requested", want: DefaultLanguage},
+		{name: "empty preferences", preferences: []string{"", ""}, want: DefaultLanguage},
+		{name: "exact match", preferences: []string{"zh-CN"}, want: "zh-CN"},
+		{name: "base language", preferences: []string{"zh"}, want: "zh-CN"},
+		{name: "regional variant", preferences: []string{"en-GB"}, want: "en-US"},
+		{name: "accept language order", preferences: []string{"fr-FR,zh;q=0.8,en;q=0.5"}, want: "zh-CN"},
+		{name: "unsupported language", preferences: []string{"fr-FR"}, want: DefaultLanguage},
+		{name: "malformed header", preferences: []string{";;;q=x"}, want: DefaultLanguage},
+		{name: "cookie before header", preferences: []string{"zh-CN", "en-US"}, want: "zh-CN"},
+		{name: "unsupported cookie falls back to header", preferences: []string{"fr", "zh-CN"}, want: "zh-CN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Negotiate(tt.preferences...); got != tt.want {
+				t.Errorf("Negotiate(%q) = %q, want %q", tt.preferences, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		lang, key string
+		params    map[string]any
+		want      string
+		wantOK    bool
+	}{
+		{lang: "en-US", key: "errors.not_found", want: "Resource not found", wantOK: true},
+		{lang: "zh-CN", key: "fields.required", params: map[string]any{"field": "name"},
+			want: "name 为必填项", wantOK: true},
+		{lang: "en-US", key: "errors.missing"},
+		{lang: "fr-FR", key: "errors.not_found"},
+	}
+	for _, tt := range tests {
+		got, ok := Translate(tt.lang, tt.key, tt.params)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Translate(%q, %q) = %q, %v, want %q, %v", tt.lang, tt.key, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// Every locale must translate the same keys, so that no language falls back
+// to English titles or field messages
+func TestCatalogsHaveTheSameKeys(t *testing.T) {
+	reference := catalogs[DefaultLanguage].messages
+	for _, lang := range supportedLanguages[1:] {
+		messages := catalogs[lang].messages
+		for key := range reference {
+			if _, ok := messages[key]; !ok {
+				t.Errorf("%s is missing %q", lang, key)
+			}
+		}
+		for key := range messages {
+			if _, ok := reference[key]; !ok {
+				t.Errorf("%s has %q, which %s lacks", lang, key, DefaultLanguage)
+			}
+		}
+	}
+}
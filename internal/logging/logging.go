@@ -0,0 +1,19 @@
+package logging
+
+import (
+	"log"
+	"log/slog"
+)
+
+// Setup wraps the default logger installed by go-webmods, which already
+// honours log.level and log.format, so that sensitive attributes are
+// redacted everywhere. The standard log package writes through it as well.
+func Setup() {
+	slog.SetDefault(slog.New(NewRedactingHandler(slog.Default().Handler())))
+}
+
+// ServerErrorLog returns a logger for http.Server errors, such as failed TLS
+// handshakes or certificate requests, written through slog as warnings
+func ServerErrorLog() *log.Logger {
+	return slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn)
+}
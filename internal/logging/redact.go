@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// RedactedValue replaces the value of sensitive attributes
+const RedactedValue = "[REDACTED]"
+
+// sensitiveKeyParts marks an attribute or query parameter as sensitive when
+// its lowercased key contains any of them
+var sensitiveKeyParts = []string{
+	"authorization",
+	"cookie",
+	"password",
+	"secret",
+	"session",
+	"token",
+}
+
+// sensitiveQueryParams are OAuth parameters that grant access on their own
+var sensitiveQueryParams = []string{"code", "state"}
+
+// IsSensitiveKey reports whether values under key must not be logged
+func IsSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactQuery renders a query string with sensitive parameter values replaced
+func RedactQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+
+	redacted := make(url.Values, len(query))
+	for key, values := range query {
+		isOAuthParam := slices.ContainsFunc(sensitiveQueryParams, func(param string) bool {
+			return strings.EqualFold(param, key)
+		})
+		if IsSensitiveKey(key) || isOAuthParam {
+			redacted[key] = []string{RedactedValue}
+			continue
+		}
+		redacted[key] = values
+	}
+	return redacted.Encode()
+}
+
+// RedactingHandler replaces the values of sensitive attributes, including
+// those nested in groups, before passing records to the wrapped handler
+type RedactingHandler struct {
+	handler slog.Handler
+}
+
+// NewRedactingHandler wraps handler
+func NewRedactingHandler(handler slog.Handler) *RedactingHandler {
+	return &RedactingHandler{handler: handler}
+}
+
+// Enabled implements slog.Handler
+func (h *RedactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler
+func (h *RedactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(attr))
+		return true
+	})
+	return h.handler.Handle(ctx, redacted)
+}
+
+// WithAttrs implements slog.Handler
+func (h *RedactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		redacted = append(redacted, redactAttr(attr))
+	}
+	return &RedactingHandler{handler: h.handler.WithAttrs(redacted)}
+}
+
+// WithGroup implements slog.Handler
+func (h *RedactingHandler) WithGroup(name string) slog.Handler {
+	return &RedactingHandler{handler: h.handler.WithGroup(name)}
+}
+
+func redactAttr(attr slog.Attr) slog.Attr {
+	if IsSensitiveKey(attr.Key) {
+		return slog.String(attr.Key, RedactedValue)
+	}
+
+	attr.Value = attr.Value.Resolve()
+	if attr.Value.Kind() != slog.KindGroup {
+		return attr
+	}
+
+	group := attr.Value.Group()
+	redacted := make([]slog.Attr, 0, len(group))
+	for _, member := range group {
+		redacted = append(redacted, redactAttr(member))
+	}
+	return slog.Attr{Key: attr.Key, Value: slog.GroupValue(redacted...)}
+}
@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/url"
+	"testing"
+)
+
+func TestIsSensitiveKey(t *testing.T) {
+	for key, want := range map[string]bool{
+		"Authorization":  true,
+		"set-cookie":     true,
+		"user_password":  true,
+		"ClientSecret":   true,
+		"session_id":     true,
+		"refresh_token":  true,
+		"user":           false,
+		"path":           false,
+		"code":           false,
+		"request_id":     false,
+		"authentication": false,
+	} {
+		if got := IsSensitiveKey(key); got != want {
+			t.Errorf("IsSensitiveKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestRedactQuery(t *testing.T) {
+	tests := []struct {
+		query url.Values
+		want  string
+	}{
+		{query: nil, want: ""},
+		{query: url.Values{"page": {"2"}}, want: "page=2"},
+		{
+			query: url.Values{"code": {"abc"}, "State": {"xyz"}, "access_token": {"t1", "t2"}, "q": {"go"}},
+			want:  "State=%5BREDACTED%5D&access_token=%5BREDACTED%5D&code=%5BREDACTED%5D&q=go",
+		},
+	}
+	for _, tt := range tests {
+		if got := RedactQuery(tt.query); got != tt.want {
+			t.Errorf("RedactQuery(%v) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+// credentials logs as a group holding a secret
+type credentials struct{ user, password string }
+
+func (c credentials) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("user", c.user), slog.String("password", c.password))
+}
+
+// panicValuer must never be resolved
+type panicValuer struct{}
+
+func (panicValuer) LogValue() slog.Value {
+	panic("sensitive values must not be resolved")
+}
+
+func TestRedactingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewRedactingHandler(slog.NewJSONHandler(&buf, nil)))
+
+	logger.
+		With("cookie", "c=1", "service", "auth").
+		WithGroup("req").
+		Info("request",
+			"token", "t0k3n",
+			"session", panicValuer{},
+			slog.Group("header", "Authorization", "Bearer x", "Accept", "text/html"),
+			"login", credentials{user: "alice", password: "hunter2"},
+		)
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	req, _ := got["req"].(map[string]any)
+	header, _ := req["header"].(map[string]any)
+	login, _ := req["login"].(map[string]any)
+
+	for name, tt := range map[string]struct {
+		got, want any
+	}{
+		"cookie":                   {got["cookie"], RedactedValue},
+		"service":                  {got["service"], "auth"},
+		"req.token":                {req["token"], RedactedValue},
+		"req.session":              {req["session"], RedactedValue},
+		"req.header.Authorization": {header["Authorization"], RedactedValue},
+		"req.header.Accept":        {header["Accept"], "text/html"},
+		"req.login.user":           {login["user"], "alice"},
+		"req.login.password":       {login["password"], RedactedValue},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", name, tt.got, tt.want)
+		}
+	}
+	if bytes.Contains(buf.Bytes(), []byte("hunter2")) || bytes.Contains(buf.Bytes(), []byte("t0k3n")) {
+		t.Errorf("secret logged: %s", buf.String())
+	}
+}
+
+func TestRedactingHandlerEnabled(t *testing.T) {
+	h := NewRedactingHandler(slog.NewJSONHandler(&bytes.Buffer{}, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	if h.Enabled(t.Context(), slog.LevelInfo) || !h.Enabled(t.Context(), slog.LevelError) {
+		t.Error("Enabled does not follow the wrapped handler")
+	}
+}
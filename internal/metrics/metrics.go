@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "reborn"
+
+// Registry holds every collector exposed on the metrics endpoint
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method, route template and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	grpcClientRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc_client",
+		Name:      "requests_total",
+		Help:      "Outgoing gRPC calls by method and status code.",
+	}, []string{"method", "code"})
+
+	grpcClientDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc_client",
+		Name:      "request_duration_seconds",
+		Help:      "Outgoing gRPC call latency by method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+
+	rateLimitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "rate_limiter",
+		Name:      "rejections_total",
+		Help:      "Requests rejected by the rate limiter by route template.",
+	}, []string{"route"})
+
+	staticFileHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "static",
+		Name:      "file_hits_total",
+		Help:      "Files served from the website dist directory by kind.",
+	}, []string{"kind"})
+)
+
+// Kinds of static file hits
+const (
+	StaticKindAsset       = "asset"
+	StaticKindFile        = "file"
+	StaticKindSPAFallback = "spa_fallback"
+	StaticKindMissing     = "missing"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		grpcClientRequests,
+		grpcClientDuration,
+		rateLimitRejections,
+		staticFileHits,
+	)
+}
+
+// ObserveHTTPRequest records a served HTTP request
+func ObserveHTTPRequest(method, route, status string, duration time.Duration) {
+	httpRequests.WithLabelValues(method, route, status).Inc()
+	httpDuration.WithLabelValues(method, route, status).Observe(duration.Seconds())
+}
+
+// IncRateLimitRejection records a request rejected by the rate limiter
+func IncRateLimitRejection(route string) {
+	rateLimitRejections.WithLabelValues(route).Inc()
+}
+
+// IncStaticFileHit records a file served from the website dist directory
+func IncStaticFileHit(kind string) {
+	staticFileHits.WithLabelValues(kind).Inc()
+}
+
+// GRPCClientInterceptor records the count and latency of outgoing calls
+func GRPCClientInterceptor(
+	ctx context.Context,
+	method string,
+	req, reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+
+	code := status.Code(err).String()
+	method = strings.TrimPrefix(method, "/")
+	grpcClientRequests.WithLabelValues(method, code).Inc()
+	grpcClientDuration.WithLabelValues(method, code).Observe(time.Since(start).Seconds())
+	return err
+}
+
+// Handler serves the registry in the Prometheus exposition format. When
+// bearerToken is not empty, requests must present it.
+func Handler(bearerToken string) http.Handler {
+	handler := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+	if bearerToken == "" {
+		return handler
+	}
+
+	expected := []byte("Bearer " + bearerToken)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provided := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(provided, expected) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// NewServer returns an HTTP server that only serves metrics on path, for
+// deployments that keep metrics off the public listener
+func NewServer(addr, path, bearerToken string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle(path, Handler(bearerToken))
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
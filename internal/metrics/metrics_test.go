@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandlerBearerToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "no token configured", wantStatus: http.StatusOK},
+		{name: "valid token", token: "s3cret", authorization: "Bearer s3cret", wantStatus: http.StatusOK},
+		{name: "missing header", token: "s3cret", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", token: "s3cret", authorization: "Bearer s3cres", wantStatus: http.StatusUnauthorized},
+		{name: "token prefix", token: "s3cret", authorization: "Bearer s3c", wantStatus: http.StatusUnauthorized},
+		{name: "wrong scheme", token: "s3cret", authorization: "Basic s3cret", wantStatus: http.StatusUnauthorized},
+		{name: "lowercase scheme", token: "s3cret", authorization: "bearer s3cret", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			Handler(tt.token).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			challenge := rec.Header().Get("WWW-Authenticate")
+			if (tt.wantStatus == http.StatusUnauthorized) != (challenge != "") {
+				t.Errorf("WWW-Authenticate = %q with status %d", challenge, rec.Code)
+			}
+		})
+	}
+}
+
+func TestNewServerOnlyServesPath(t *testing.T) {
+	server := NewServer(":0", "/metrics", "")
+	for target, want := range map[string]int{
+		"/metrics": http.StatusOK,
+		"/":        http.StatusNotFound,
+		"/debug":   http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != want {
+			t.Errorf("%s: status = %d, want %d", target, rec.Code, want)
+		}
+	}
+}
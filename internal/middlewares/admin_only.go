@@ -1,14 +1,14 @@
 package middlewares
 
 import (
+	"log/slog"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/oj-lab/reborn/internal/apperror"
 	"github.com/oj-lab/reborn/internal/services"
 	"github.com/oj-lab/user-service/pkg/userpb"
-	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -19,25 +19,38 @@ func AdminOnly(authService *services.AuthService) echo.MiddlewareFunc {
 		return func(c echo.Context) error {
 			// Check if user is authenticated
 			if !IsAuthenticated(c) {
-				return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+				return apperror.New(
+					http.StatusUnauthorized,
+					apperror.CodeUnauthenticated,
+					"Authentication required",
+				)
 			}
 
 			// Get user token from context
 			userToken := GetUserToken(c)
 			if userToken == "" {
-				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid user token")
+				return apperror.New(
+					http.StatusUnauthorized,
+					apperror.CodeInvalidToken,
+					"Invalid user token",
+				)
 			}
 
 			// Check if auth service is available
 			if authService == nil || !authService.IsHealthy() {
-				return echo.NewHTTPError(http.StatusServiceUnavailable, "User service unavailable")
+				return apperror.New(
+					http.StatusServiceUnavailable,
+					apperror.CodeServiceUnavailable,
+					"User service unavailable",
+				)
 			}
 
 			// Get auth service client
 			authClient := authService.GetClient()
 			if authClient == nil {
-				return echo.NewHTTPError(
+				return apperror.New(
 					http.StatusServiceUnavailable,
+					apperror.CodeServiceUnavailable,
 					"User service client unavailable",
 				)
 			}
@@ -52,39 +65,21 @@ func AdminOnly(authService *services.AuthService) echo.MiddlewareFunc {
 			// Get current user information to check role
 			user, err := userServiceClient.GetCurrentUser(ctx, &emptypb.Empty{})
 			if err != nil {
-				// Handle gRPC errors
-				if grpcStatus, ok := status.FromError(err); ok {
-					switch grpcStatus.Code() {
-					case codes.Unauthenticated:
-						return echo.NewHTTPError(
-							http.StatusUnauthorized,
-							"Invalid or expired token",
-						)
-					case codes.NotFound:
-						return echo.NewHTTPError(http.StatusNotFound, "User not found")
-					case codes.PermissionDenied:
-						return echo.NewHTTPError(http.StatusForbidden, "Permission denied")
-					default:
-						return echo.NewHTTPError(
-							http.StatusInternalServerError,
-							"Failed to get user information",
-						)
-					}
-				}
-				return echo.NewHTTPError(
-					http.StatusInternalServerError,
-					"Failed to get user information",
-				)
+				return apperror.FromGRPC(err, "Failed to get user information")
 			}
 
 			// Check if user is admin
 			if user.Role != userpb.UserRole_ADMIN {
-				return echo.NewHTTPError(http.StatusForbidden, "Admin access required")
+				return apperror.New(
+					http.StatusForbidden,
+					apperror.CodeAdminRequired,
+					"Admin access required",
+				)
 			}
 
 			// Store user info in context for subsequent handlers
 			c.Set("current_user", user)
-			c.Logger().Debug("Admin user authenticated")
+			slog.DebugContext(c.Request().Context(), "Admin user authenticated", "user_id", user.Id)
 
 			return next(c)
 		}
@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/oj-lab/reborn/internal/apperror"
+)
+
+// BodyLimit returns a middleware that rejects request bodies larger than
+// limit bytes with 413. A limit of zero or less disables the check.
+func BodyLimit(limit int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if limit <= 0 {
+			return next
+		}
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.ContentLength > limit {
+				return errPayloadTooLarge()
+			}
+			// Bodies without a declared length fail once the limit is read
+			req.Body = http.MaxBytesReader(c.Response(), req.Body, limit)
+			return next(c)
+		}
+	}
+}
+
+func errPayloadTooLarge() *apperror.Error {
+	return apperror.New(
+		http.StatusRequestEntityTooLarge,
+		apperror.CodePayloadTooLarge,
+		"Request body is too large",
+	)
+}
@@ -1,44 +1,103 @@
 package middlewares
 
 import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/oj-lab/go-webmods/app"
+	"github.com/oj-lab/reborn/internal/logging"
+	"github.com/oj-lab/reborn/internal/telemetry"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// CORS returns a CORS middleware with common settings
-func CORS() echo.MiddlewareFunc {
-	return middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"}, // Configure based on your needs
-		AllowMethods: []string{echo.GET, echo.HEAD, echo.PUT, echo.PATCH, echo.POST, echo.DELETE},
-		AllowHeaders: []string{
-			echo.HeaderOrigin,
-			echo.HeaderContentType,
-			echo.HeaderAccept,
-			echo.HeaderAuthorization,
+// RequestID returns a request ID middleware that also stores the ID in the
+// request context, so it reaches logs and downstream gRPC calls
+func RequestID() echo.MiddlewareFunc {
+	return middleware.RequestIDWithConfig(middleware.RequestIDConfig{
+		RequestIDHandler: func(c echo.Context, requestID string) {
+			ctx := telemetry.WithRequestID(c.Request().Context(), requestID)
+			ctx = app.WithLogAttrs(ctx, slog.String("request_id", requestID))
+			c.SetRequest(c.Request().WithContext(ctx))
 		},
 	})
 }
 
-// RequestID returns a request ID middleware
-func RequestID() echo.MiddlewareFunc {
-	return middleware.RequestID()
+// Tracing returns a middleware that starts a server span for each request
+// and adds its trace ID to the request log attributes
+func Tracing(serviceName string) echo.MiddlewareFunc {
+	traceMiddleware := otelecho.Middleware(
+		serviceName,
+		otelecho.WithSkipper(func(c echo.Context) bool {
+			return strings.HasPrefix(c.Request().URL.Path, "/health")
+		}),
+	)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return traceMiddleware(func(c echo.Context) error {
+			ctx := c.Request().Context()
+			if traceID := telemetry.TraceID(ctx); traceID != "" {
+				if requestID := telemetry.RequestIDFromContext(ctx); requestID != "" {
+					trace.SpanFromContext(ctx).
+						SetAttributes(attribute.String("http.request_id", requestID))
+				}
+				ctx = app.WithLogAttrs(ctx, slog.String("trace_id", traceID))
+				c.SetRequest(c.Request().WithContext(ctx))
+			}
+			return next(c)
+		})
+	}
 }
 
-// Logger returns a logger middleware
+// Logger returns a middleware that writes one structured access log line per
+// request. Request and trace IDs are attached from the request context.
 func Logger() echo.MiddlewareFunc {
-	return middleware.LoggerWithConfig(middleware.LoggerConfig{
-		Format: "${time_rfc3339} ${status} ${method} ${uri} ${latency_human}\n",
-	})
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			if err := next(c); err != nil {
+				// Let the error handler write the response so status and size are final
+				c.Error(err)
+			}
+
+			req, res := c.Request(), c.Response()
+			attrs := []slog.Attr{
+				slog.String("method", req.Method),
+				slog.String("path", req.URL.Path),
+				slog.String("route", routeLabel(c)),
+				slog.Int("status", res.Status),
+				slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+				slog.Int64("bytes_in", max(req.ContentLength, 0)),
+				slog.Int64("bytes_out", res.Size),
+				slog.String("remote_ip", c.RealIP()),
+				slog.String("user_agent", req.UserAgent()),
+			}
+			if query := logging.RedactQuery(req.URL.Query()); query != "" {
+				attrs = append(attrs, slog.String("query", query))
+			}
+			if userID := GetUserID(c); userID != 0 {
+				attrs = append(attrs, slog.Uint64("user_id", userID))
+			}
+
+			level := slog.LevelInfo
+			switch {
+			case res.Status >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case res.Status >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			}
+			slog.LogAttrs(req.Context(), level, "HTTP request", attrs...)
+			return nil
+		}
+	}
 }
 
 // Recover returns a recover middleware
 func Recover() echo.MiddlewareFunc {
 	return middleware.Recover()
 }
-
-// RateLimiter returns a rate limiter middleware
-func RateLimiter() echo.MiddlewareFunc {
-	return middleware.RateLimiter(
-		middleware.NewRateLimiterMemoryStore(20),
-	) // 20 requests per second
-}
@@ -0,0 +1,225 @@
+package middlewares
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	config "github.com/oj-lab/reborn/configs"
+	"github.com/oj-lab/reborn/internal/compress"
+)
+
+// Compress returns a middleware that compresses responses of the configured
+// content types with brotli or gzip, whichever the client prefers, once they
+// reach the minimum size. Responses that are already encoded, such as
+// precompressed static files, are left alone.
+func Compress(cfg config.CompressionConfig) echo.MiddlewareFunc {
+	if !cfg.Enabled {
+		return func(next echo.HandlerFunc) echo.HandlerFunc {
+			return next
+		}
+	}
+
+	contentTypes := make(map[string]bool, len(cfg.ContentTypes))
+	for _, ct := range cfg.ContentTypes {
+		contentTypes[mediaType(ct)] = true
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			// Bodiless, partial and upgraded responses are never compressed
+			if req.Method == http.MethodHead ||
+				req.Header.Get(echo.HeaderUpgrade) != "" ||
+				req.Header.Get("Range") != "" {
+				return next(c)
+			}
+			coding := compress.Negotiate(
+				req.Header.Get(echo.HeaderAcceptEncoding),
+				compress.Encodings...,
+			)
+			if coding == "" {
+				return next(c)
+			}
+
+			res := c.Response()
+			cw := &compressWriter{
+				ResponseWriter: res.Writer,
+				coding:         coding,
+				minSize:        int(cfg.MinSize),
+				contentTypes:   contentTypes,
+			}
+			res.Writer = cw
+			defer func() {
+				res.Writer = cw.ResponseWriter
+			}()
+
+			if err := next(c); err != nil {
+				// Let the error handler write the response through the encoder
+				c.Error(err)
+			}
+			return cw.finish()
+		}
+	}
+}
+
+// compressWriter buffers the start of a response until it is known whether
+// it is worth compressing
+type compressWriter struct {
+	http.ResponseWriter
+	coding       string
+	minSize      int
+	contentTypes map[string]bool
+
+	status  int
+	buf     []byte
+	decided bool
+	encoder compress.Writer
+}
+
+// WriteHeader records the status, sending it once the encoding is decided
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided || w.status != 0 {
+		return
+	}
+	w.status = code
+	if code < http.StatusOK || code == http.StatusNoContent ||
+		code == http.StatusNotModified || code == http.StatusPartialContent {
+		_ = w.passthrough()
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		if !w.eligible() {
+			if err := w.passthrough(); err != nil {
+				return 0, err
+			}
+			return w.ResponseWriter.Write(b)
+		}
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.minSize {
+			return len(b), nil
+		}
+		if err := w.startEncoding(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends what has been written so far, compressed when eligible, for
+// streamed responses
+func (w *compressWriter) Flush() {
+	if !w.decided && w.status != 0 {
+		if w.eligible() && len(w.buf) > 0 {
+			_ = w.startEncoding()
+		} else {
+			_ = w.passthrough()
+		}
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Flush()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the underlying writer for http.ResponseController
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// eligible reports whether the response may be compressed, based on its
+// headers. The Vary header is added for every response that qualifies.
+func (w *compressWriter) eligible() bool {
+	header := w.Header()
+	if header.Get(echo.HeaderContentEncoding) != "" || header.Get("Content-Range") != "" ||
+		!w.contentTypes[mediaType(header.Get(echo.HeaderContentType))] {
+		return false
+	}
+	if !varies(header, echo.HeaderAcceptEncoding) {
+		header.Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+	}
+	return true
+}
+
+// varies reports whether the Vary header already lists name
+func varies(header http.Header, name string) bool {
+	for _, value := range header.Values(echo.HeaderVary) {
+		for _, field := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// passthrough sends the response unmodified
+func (w *compressWriter) passthrough() error {
+	w.decided = true
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// startEncoding sends the headers of the compressed response and the
+// buffered body
+func (w *compressWriter) startEncoding() error {
+	w.decided = true
+	header := w.Header()
+	header.Del(echo.HeaderContentLength)
+	header.Set(echo.HeaderContentEncoding, w.coding)
+	// The compressed body is no longer byte for byte the tagged one
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set("ETag", "W/"+etag)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	w.encoder = compress.NewWriter(w.coding, w.ResponseWriter)
+	_, err := w.encoder.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// finish sends a response that stayed below the minimum size and completes
+// the compressed stream
+func (w *compressWriter) finish() error {
+	if !w.decided {
+		if w.status == 0 {
+			return nil
+		}
+		w.eligible()
+		return w.passthrough()
+	}
+	if w.encoder != nil {
+		return w.encoder.Close()
+	}
+	return nil
+}
+
+// mediaType returns the lowercased media type of a Content-Type value,
+// without parameters
+func mediaType(contentType string) string {
+	mt, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mt))
+}
@@ -0,0 +1,235 @@
+package middlewares
+
+import (
+	"cmp"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/labstack/echo/v4"
+	config "github.com/oj-lab/reborn/configs"
+)
+
+var testCompression = config.CompressionConfig{
+	Enabled:      true,
+	MinSize:      64,
+	ContentTypes: []string{"application/json", "text/html"},
+}
+
+// serveCompressed runs handler behind the Compress middleware
+func serveCompressed(
+	t *testing.T,
+	cfg config.CompressionConfig,
+	req *http.Request,
+	handler echo.HandlerFunc,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	e := echo.New()
+	e.HTTPErrorHandler = ErrorHandler
+	e.Use(Compress(cfg))
+	e.Any("/", handler)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func jsonHandler(body string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set("ETag", `"v1"`)
+		return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, []byte(body))
+	}
+}
+
+// decode returns the body of rec, decompressed with its Content-Encoding
+func decode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var r io.Reader = rec.Body
+	switch rec.Header().Get(echo.HeaderContentEncoding) {
+	case "br":
+		r = brotli.NewReader(r)
+	case "gzip":
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			t.Fatalf("gzip: %v", err)
+		}
+		r = gz
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	return string(body)
+}
+
+func TestCompress(t *testing.T) {
+	large := `{"data":"` + strings.Repeat("x", 256) + `"}`
+	small := `{"ok":true}`
+
+	tests := []struct {
+		name         string
+		method       string
+		header       map[string]string
+		handler      echo.HandlerFunc
+		wantEncoding string
+		wantETag     string
+		wantVary     bool
+		// wantBody is the decoded body, when checked
+		wantBody string
+	}{
+		{
+			name:         "brotli preferred",
+			header:       map[string]string{"Accept-Encoding": "gzip, br"},
+			handler:      jsonHandler(large),
+			wantEncoding: "br", wantETag: `W/"v1"`, wantVary: true, wantBody: large,
+		},
+		{
+			name:         "gzip",
+			header:       map[string]string{"Accept-Encoding": "gzip"},
+			handler:      jsonHandler(large),
+			wantEncoding: "gzip", wantETag: `W/"v1"`, wantVary: true, wantBody: large,
+		},
+		{
+			name:     "below the minimum size",
+			header:   map[string]string{"Accept-Encoding": "br"},
+			handler:  jsonHandler(small),
+			wantETag: `"v1"`, wantVary: true, wantBody: small,
+		},
+		{
+			name:     "no accepted encoding",
+			header:   map[string]string{"Accept-Encoding": "identity"},
+			handler:  jsonHandler(large),
+			wantETag: `"v1"`,
+		},
+		{
+			name:     "HEAD request",
+			method:   http.MethodHead,
+			header:   map[string]string{"Accept-Encoding": "br"},
+			handler:  jsonHandler(large),
+			wantETag: `"v1"`,
+		},
+		{
+			name:     "range request",
+			header:   map[string]string{"Accept-Encoding": "br", "Range": "bytes=0-9"},
+			handler:  jsonHandler(large),
+			wantETag: `"v1"`,
+		},
+		{
+			name:     "upgrade request",
+			header:   map[string]string{"Accept-Encoding": "br", "Connection": "Upgrade", "Upgrade": "websocket"},
+			handler:  jsonHandler(large),
+			wantETag: `"v1"`,
+		},
+		{
+			name:   "other content type",
+			header: map[string]string{"Accept-Encoding": "br"},
+			handler: func(c echo.Context) error {
+				return c.Blob(http.StatusOK, "image/png", []byte(large))
+			},
+		},
+		{
+			name:   "already encoded",
+			header: map[string]string{"Accept-Encoding": "br"},
+			handler: func(c echo.Context) error {
+				c.Response().Header().Set(echo.HeaderContentEncoding, "gzip")
+				return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, []byte(large))
+			},
+			wantEncoding: "gzip",
+		},
+		{
+			name:   "weak ETag kept",
+			header: map[string]string{"Accept-Encoding": "br"},
+			handler: func(c echo.Context) error {
+				c.Response().Header().Set("ETag", `W/"v1"`)
+				return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, []byte(large))
+			},
+			wantEncoding: "br", wantETag: `W/"v1"`, wantVary: true,
+		},
+		{
+			name:   "written in small chunks",
+			header: map[string]string{"Accept-Encoding": "br"},
+			handler: func(c echo.Context) error {
+				c.Response().Header().Set(echo.HeaderContentType, "text/html; charset=utf-8")
+				c.Response().WriteHeader(http.StatusOK)
+				for _, chunk := range strings.SplitAfter(large, "x") {
+					if _, err := c.Response().Write([]byte(chunk)); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			wantEncoding: "br", wantVary: true, wantBody: large,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(cmp.Or(tt.method, http.MethodGet), "/", nil)
+			for name, value := range tt.header {
+				req.Header.Set(name, value)
+			}
+			rec := serveCompressed(t, testCompression, req, tt.handler)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d", rec.Code)
+			}
+			header := rec.Header()
+			if got := header.Get(echo.HeaderContentEncoding); got != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if got := header.Get("ETag"); got != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", got, tt.wantETag)
+			}
+			if got := header.Get(echo.HeaderVary) == echo.HeaderAcceptEncoding; got != tt.wantVary {
+				t.Errorf("Vary = %q, want Accept-Encoding: %v", header.Get(echo.HeaderVary), tt.wantVary)
+			}
+			if tt.wantEncoding != "" && header.Get(echo.HeaderContentLength) != "" {
+				t.Errorf("Content-Length %s kept on a compressed response", header.Get(echo.HeaderContentLength))
+			}
+			if tt.wantBody != "" {
+				if body := decode(t, rec); body != tt.wantBody {
+					t.Errorf("body = %q, want %q", body, tt.wantBody)
+				}
+			}
+		})
+	}
+}
+
+func TestCompressErrorResponse(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := serveCompressed(t, config.CompressionConfig{
+		Enabled:      true,
+		ContentTypes: []string{"application/problem+json"},
+	}, req, func(echo.Context) error {
+		return echo.NewHTTPError(http.StatusTeapot, "short and stout")
+	})
+
+	if rec.Code != http.StatusTeapot || rec.Header().Get(echo.HeaderContentEncoding) != "gzip" {
+		t.Errorf("status %d, encoding %q, want a compressed %d", rec.Code,
+			rec.Header().Get(echo.HeaderContentEncoding), http.StatusTeapot)
+	}
+	if !strings.Contains(decode(t, rec), "short and stout") {
+		t.Errorf("error body lost: %q", rec.Body.String())
+	}
+}
+
+func TestCompressSkipsBodilessStatuses(t *testing.T) {
+	for _, status := range []int{http.StatusNoContent, http.StatusNotModified} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "br")
+		rec := serveCompressed(t, testCompression, req, func(c echo.Context) error {
+			c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			return c.NoContent(status)
+		})
+
+		if rec.Code != status || rec.Header().Get(echo.HeaderContentEncoding) != "" || rec.Body.Len() != 0 {
+			t.Errorf("%d: got status %d, encoding %q, body %q", status, rec.Code,
+				rec.Header().Get(echo.HeaderContentEncoding), rec.Body.String())
+		}
+	}
+}
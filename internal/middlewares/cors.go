@@ -0,0 +1,162 @@
+package middlewares
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	config "github.com/oj-lab/reborn/configs"
+)
+
+const (
+	anyOrigin         = "*"
+	subdomainWildcard = "*."
+)
+
+// corsRoute is a CORS middleware applied to requests under a path prefix
+type corsRoute struct {
+	prefix     string
+	middleware echo.MiddlewareFunc
+}
+
+// CORS returns a CORS middleware for the configured policies. Requests use
+// the group policy with the longest matching path prefix, or the top-level
+// policy otherwise. It fails for policies that would let any origin send
+// credentials.
+func CORS(cfg config.CORSConfig) (echo.MiddlewareFunc, error) {
+	defaultMiddleware, err := corsMiddleware(cfg.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cors policy: %w", err)
+	}
+
+	routes := make([]corsRoute, 0, len(cfg.Groups))
+	for name, policy := range cfg.Groups {
+		if policy.PathPrefix == "" {
+			return nil, fmt.Errorf("cors group %s has no path_prefix", name)
+		}
+		m, err := corsMiddleware(policy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cors policy for group %s: %w", name, err)
+		}
+		routes = append(routes, corsRoute{prefix: policy.PathPrefix, middleware: m})
+	}
+	// Check the most specific prefixes first
+	slices.SortFunc(routes, func(a, b corsRoute) int {
+		return cmp.Compare(len(b.prefix), len(a.prefix))
+	})
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		defaultHandler := defaultMiddleware(next)
+		handlers := make([]echo.HandlerFunc, len(routes))
+		for i, route := range routes {
+			handlers[i] = route.middleware(next)
+		}
+
+		return func(c echo.Context) error {
+			path := c.Request().URL.Path
+			for i, route := range routes {
+				if strings.HasPrefix(path, route.prefix) {
+					return handlers[i](c)
+				}
+			}
+			return defaultHandler(c)
+		}
+	}, nil
+}
+
+// corsMiddleware builds the echo CORS middleware for a single policy
+func corsMiddleware(policy config.CORSPolicy) (echo.MiddlewareFunc, error) {
+	if len(policy.AllowOrigins) == 0 {
+		return nil, errors.New("allow_origins is empty")
+	}
+
+	corsCfg := middleware.CORSConfig{
+		AllowMethods:     policy.AllowMethods,
+		AllowHeaders:     policy.AllowHeaders,
+		ExposeHeaders:    policy.ExposeHeaders,
+		AllowCredentials: policy.AllowCredentials,
+		MaxAge:           int(policy.MaxAge.Seconds()),
+	}
+
+	if slices.Contains(policy.AllowOrigins, anyOrigin) {
+		if policy.AllowCredentials {
+			return nil, errors.New(`allow_credentials cannot be combined with origin "*"`)
+		}
+		corsCfg.AllowOrigins = []string{anyOrigin}
+		return middleware.CORSWithConfig(corsCfg), nil
+	}
+
+	matchers := make([]originMatcher, 0, len(policy.AllowOrigins))
+	for _, origin := range policy.AllowOrigins {
+		m, err := parseOriginPattern(origin)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	corsCfg.AllowOriginFunc = func(origin string) (bool, error) {
+		return slices.ContainsFunc(matchers, func(m originMatcher) bool {
+			return m.match(origin)
+		}), nil
+	}
+	return middleware.CORSWithConfig(corsCfg), nil
+}
+
+// originMatcher matches request origins against an allowed origin, which
+// may cover all subdomains of a host
+type originMatcher struct {
+	scheme string
+	host   string
+	port   string
+	// subdomains matches any subdomain of host, but not host itself
+	subdomains bool
+}
+
+// parseOriginPattern parses an origin such as "https://example.com" or
+// "https://*.example.com:8443"
+func parseOriginPattern(pattern string) (originMatcher, error) {
+	u, err := url.Parse(pattern)
+	if err != nil || u.Scheme == "" || u.Host == "" ||
+		(u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return originMatcher{}, fmt.Errorf("invalid origin %q", pattern)
+	}
+
+	m := originMatcher{
+		scheme: strings.ToLower(u.Scheme),
+		host:   strings.ToLower(u.Hostname()),
+		port:   u.Port(),
+	}
+	if rest, ok := strings.CutPrefix(m.host, subdomainWildcard); ok {
+		m.host = rest
+		m.subdomains = true
+	}
+	if m.host == "" || strings.Contains(m.host, anyOrigin) {
+		return originMatcher{}, fmt.Errorf(
+			"invalid origin %q, only a leading subdomain wildcard is supported",
+			pattern,
+		)
+	}
+	return m, nil
+}
+
+func (m originMatcher) match(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return false
+	}
+	if strings.ToLower(u.Scheme) != m.scheme || u.Port() != m.port {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if !m.subdomains {
+		return host == m.host
+	}
+	sub, ok := strings.CutSuffix(host, "."+m.host)
+	return ok && sub != ""
+}
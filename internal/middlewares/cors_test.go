@@ -0,0 +1,185 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	config "github.com/oj-lab/reborn/configs"
+)
+
+func TestParseOriginPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    originMatcher
+		wantErr bool
+	}{
+		{pattern: "https://example.com", want: originMatcher{scheme: "https", host: "example.com"}},
+		{pattern: "HTTPS://Example.COM/", want: originMatcher{scheme: "https", host: "example.com"}},
+		{
+			pattern: "https://*.example.com:8443",
+			want:    originMatcher{scheme: "https", host: "example.com", port: "8443", subdomains: true},
+		},
+		{pattern: "example.com", wantErr: true},
+		{pattern: "https://example.com/app", wantErr: true},
+		{pattern: "https://example.com?a=1", wantErr: true},
+		{pattern: "https://*", wantErr: true},
+		{pattern: "https://*.", wantErr: true},
+		{pattern: "https://a.*.example.com", wantErr: true},
+		{pattern: "https://*.*.example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseOriginPattern(tt.pattern)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseOriginPattern(%q) = %+v, want an error", tt.pattern, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseOriginPattern(%q) = %+v, %v, want %+v", tt.pattern, got, err, tt.want)
+		}
+	}
+}
+
+func TestOriginMatcherMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		origin  string
+		want    bool
+	}{
+		{"https://*.example.com", "https://a.example.com", true},
+		{"https://*.example.com", "https://A.Example.com", true},
+		{"https://*.example.com", "https://a.b.example.com", true},
+		{"https://*.example.com", "https://example.com", false},
+		{"https://*.example.com", "https://evilexample.com", false},
+		{"https://*.example.com", "https://a.example.com.evil.com", false},
+		{"https://*.example.com", "http://a.example.com", false},
+		{"https://*.example.com", "https://a.example.com:8443", false},
+		{"https://*.example.com:8443", "https://a.example.com:8443", true},
+		{"https://*.example.com:8443", "https://a.example.com", false},
+		{"https://example.com", "https://example.com", true},
+		{"https://example.com", "https://a.example.com", false},
+		{"https://example.com", "https://example.com/", false},
+		{"https://example.com", "https://user@example.com", false},
+		{"https://example.com", "null", false},
+	}
+	for _, tt := range tests {
+		m, err := parseOriginPattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("parseOriginPattern(%q): %v", tt.pattern, err)
+		}
+		if got := m.match(tt.origin); got != tt.want {
+			t.Errorf("%s matches %s = %v, want %v", tt.pattern, tt.origin, got, tt.want)
+		}
+	}
+}
+
+// allowedOrigin returns the Access-Control-Allow-Origin sent to origin for
+// a request to path
+func allowedOrigin(t *testing.T, mw echo.MiddlewareFunc, path, origin string) string {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set(echo.HeaderOrigin, origin)
+	rec := httptest.NewRecorder()
+	handler := mw(func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	if err := handler(echo.New().NewContext(req, rec)); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	return rec.Header().Get(echo.HeaderAccessControlAllowOrigin)
+}
+
+func TestCORSSelectsTheLongestPrefix(t *testing.T) {
+	mw, err := CORS(config.CORSConfig{
+		Policy: config.CORSPolicy{AllowOrigins: []string{"https://app.example.com"}},
+		Groups: map[string]config.CORSPolicy{
+			"api":    {PathPrefix: "/api/", AllowOrigins: []string{"https://*.example.com"}},
+			"public": {PathPrefix: "/api/public/", AllowOrigins: []string{"*"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CORS() failed: %v", err)
+	}
+
+	tests := []struct {
+		path, origin, want string
+	}{
+		{"/", "https://app.example.com", "https://app.example.com"},
+		{"/", "https://docs.example.com", ""},
+		{"/api/users", "https://docs.example.com", "https://docs.example.com"},
+		{"/api/users", "https://other.org", ""},
+		{"/api/public/problems", "https://other.org", "*"},
+	}
+	for _, tt := range tests {
+		if got := allowedOrigin(t, mw, tt.path, tt.origin); got != tt.want {
+			t.Errorf("%s from %s: allowed origin %q, want %q", tt.path, tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestCORSRejectsInvalidPolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.CORSConfig
+		wantErr string
+	}{
+		{
+			name: "any origin with credentials",
+			cfg: config.CORSConfig{Policy: config.CORSPolicy{
+				AllowOrigins: []string{"https://example.com", "*"}, AllowCredentials: true,
+			}},
+			wantErr: "allow_credentials",
+		},
+		{
+			name: "any origin with credentials in a group",
+			cfg: config.CORSConfig{
+				Policy: config.CORSPolicy{AllowOrigins: []string{"https://example.com"}},
+				Groups: map[string]config.CORSPolicy{"api": {
+					PathPrefix: "/api/", AllowOrigins: []string{"*"}, AllowCredentials: true,
+				}},
+			},
+			wantErr: "group api",
+		},
+		{
+			name:    "no origins",
+			cfg:     config.CORSConfig{},
+			wantErr: "allow_origins is empty",
+		},
+		{
+			name: "group without a prefix",
+			cfg: config.CORSConfig{
+				Policy: config.CORSPolicy{AllowOrigins: []string{"https://example.com"}},
+				Groups: map[string]config.CORSPolicy{"api": {AllowOrigins: []string{"*"}}},
+			},
+			wantErr: "path_prefix",
+		},
+		{
+			name:    "invalid origin",
+			cfg:     config.CORSConfig{Policy: config.CORSPolicy{AllowOrigins: []string{"example.com"}}},
+			wantErr: `invalid origin "example.com"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CORS(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("CORS() error = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCORSAllowsCredentialsForListedOrigins(t *testing.T) {
+	mw, err := CORS(config.CORSConfig{Policy: config.CORSPolicy{
+		AllowOrigins: []string{"https://*.example.com"}, AllowCredentials: true,
+	}})
+	if err != nil {
+		t.Fatalf("CORS() failed: %v", err)
+	}
+	if got := allowedOrigin(t, mw, "/", "https://a.example.com"); got != "https://a.example.com" {
+		t.Errorf("allowed origin %q, want the request origin rather than *", got)
+	}
+}
@@ -1,65 +1,135 @@
 package middlewares
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
+	"github.com/oj-lab/reborn/internal/apperror"
+	"github.com/oj-lab/reborn/internal/client"
+	"github.com/oj-lab/reborn/internal/i18n"
+	"github.com/oj-lab/reborn/internal/telemetry"
 )
 
-// ErrorResponse represents a standard error response
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
-	Code    int    `json:"code"`
+// ProblemContentType is the media type of RFC 7807 error responses
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details response, extended with a stable
+// error code, field errors and the IDs needed to correlate logs. Title is
+// the localized summary of the code, while Detail keeps the message of the
+// handler that failed.
+type Problem struct {
+	Type      string                `json:"type"`
+	Title     string                `json:"title"`
+	Status    int                   `json:"status"`
+	Detail    string                `json:"detail,omitempty"`
+	Instance  string                `json:"instance,omitempty"`
+	Code      string                `json:"code"`
+	Errors    []apperror.FieldError `json:"errors,omitempty"`
+	RequestID string                `json:"request_id,omitempty"`
+	TraceID   string                `json:"trace_id,omitempty"`
 }
 
 // ErrorHandler is a custom error handler for Echo
 func ErrorHandler(err error, c echo.Context) {
-	var (
-		code = http.StatusInternalServerError
-		msg  string
-	)
+	ctx := c.Request().Context()
+	appErr := toAppError(err, c.Echo().Debug)
 
-	if he, ok := err.(*echo.HTTPError); ok {
-		code = he.Code
-		if m, ok := he.Message.(string); ok {
-			msg = m
-		} else {
-			msg = http.StatusText(code)
-		}
-	} else {
-		msg = err.Error()
+	if appErr.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(ctx, "Request failed",
+			"status", appErr.Status,
+			"code", appErr.Code,
+			"error", err,
+		)
 	}
 
-	// Don't send the error message in production for security reasons
-	if !c.Echo().Debug {
-		switch code {
-		case http.StatusNotFound:
-			msg = "Resource not found"
-		case http.StatusInternalServerError:
-			msg = "Internal server error"
-		case http.StatusUnauthorized:
-			msg = "Unauthorized"
-		case http.StatusForbidden:
-			msg = "Forbidden"
-		default:
-			msg = http.StatusText(code)
-		}
+	// Tell clients when a tripped circuit breaker will let calls through again
+	var circuitErr *client.CircuitOpenError
+	if errors.As(err, &circuitErr) {
+		retryAfter := ceilSeconds(circuitErr.RetryAfter())
+		c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(retryAfter))
 	}
 
 	// Send response
 	if !c.Response().Committed {
 		if c.Request().Method == http.MethodHead {
-			err = c.NoContent(code)
+			err = c.NoContent(appErr.Status)
 		} else {
-			err = c.JSON(code, ErrorResponse{
-				Error:   http.StatusText(code),
-				Message: msg,
-				Code:    code,
+			lang := RequestLanguage(c)
+			title, ok := i18n.Translate(lang, "errors."+appErr.Code, nil)
+			if !ok {
+				title = http.StatusText(appErr.Status)
+			}
+
+			header := c.Response().Header()
+			header.Set(echo.HeaderContentType, ProblemContentType)
+			header.Set("Content-Language", lang)
+			header.Add(echo.HeaderVary, "Accept-Language")
+			err = c.JSON(appErr.Status, Problem{
+				Type:      apperror.TypePrefix + appErr.Code,
+				Title:     title,
+				Status:    appErr.Status,
+				Detail:    appErr.Detail,
+				Instance:  c.Request().URL.Path,
+				Code:      appErr.Code,
+				Errors:    localizeFieldErrors(lang, appErr.Fields),
+				RequestID: telemetry.RequestIDFromContext(ctx),
+				TraceID:   telemetry.TraceID(ctx),
 			})
 		}
 		if err != nil {
-			c.Echo().Logger.Error(err)
+			slog.ErrorContext(ctx, "Failed to send error response", "error", err)
+		}
+	}
+}
+
+// localizeFieldErrors translates field error messages into lang, keeping the
+// original message when the catalog has no entry for the code
+func localizeFieldErrors(lang string, fields []apperror.FieldError) []apperror.FieldError {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	localized := make([]apperror.FieldError, len(fields))
+	for i, field := range fields {
+		if msg, ok := i18n.Translate(lang, "fields."+field.Code, field.Params); ok {
+			field.Message = msg
 		}
+		localized[i] = field
+	}
+	return localized
+}
+
+// toAppError converts any handler error to an application error. Messages of
+// unexpected errors are only exposed in debug mode.
+func toAppError(err error, debug bool) *apperror.Error {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	// Reading past BodyLimit fails wherever the body is consumed, e.g. in Bind
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return errPayloadTooLarge().WithInternal(err)
+	}
+
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		detail := http.StatusText(he.Code)
+		// Echo and our own middlewares only put safe messages in 4xx errors
+		if msg, ok := he.Message.(string); ok && (he.Code < http.StatusInternalServerError || debug) {
+			detail = msg
+		}
+		return apperror.New(he.Code, apperror.CodeForStatus(he.Code), detail).WithInternal(err)
+	}
+
+	detail := "Internal server error"
+	if debug {
+		detail = err.Error()
 	}
+	return apperror.New(http.StatusInternalServerError, apperror.CodeInternal, detail).
+		WithInternal(err)
 }
@@ -0,0 +1,95 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/oj-lab/reborn/internal/apperror"
+)
+
+// serveError renders err for a request with the given Accept-Language
+func serveError(t *testing.T, err error, acceptLanguage string) (*httptest.ResponseRecorder, Problem) {
+	t.Helper()
+
+	e := echo.New()
+	e.HTTPErrorHandler = ErrorHandler
+	e.GET("/", func(echo.Context) error { return err })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	var problem Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body.String(), err)
+	}
+	return rec, problem
+}
+
+func TestErrorHandlerLocalizesTitle(t *testing.T) {
+	notFound := apperror.New(http.StatusNotFound, apperror.CodeNotFound, "User not found")
+	unavailable := apperror.New(http.StatusServiceUnavailable, apperror.CodeServiceUnavailable,
+		"User service unavailable")
+
+	tests := []struct {
+		name           string
+		err            error
+		acceptLanguage string
+		wantLanguage   string
+		wantTitle      string
+		wantDetail     string
+	}{
+		{name: "default language", err: notFound,
+			wantLanguage: "en-US", wantTitle: "Resource not found", wantDetail: "User not found"},
+		{name: "english", err: notFound, acceptLanguage: "en-GB,en;q=0.9",
+			wantLanguage: "en-US", wantTitle: "Resource not found", wantDetail: "User not found"},
+		{name: "chinese", err: notFound, acceptLanguage: "zh-CN,zh;q=0.9",
+			wantLanguage: "zh-CN", wantTitle: "资源不存在", wantDetail: "User not found"},
+		{name: "chinese 503", err: unavailable, acceptLanguage: "zh",
+			wantLanguage: "zh-CN", wantTitle: "服务暂时不可用，请稍后再试", wantDetail: "User service unavailable"},
+		{name: "unexpected error stays generic", err: errors.New("dial tcp: secret host"),
+			wantLanguage: "en-US", wantTitle: "Internal server error", wantDetail: "Internal server error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, problem := serveError(t, tt.err, tt.acceptLanguage)
+
+			if got := rec.Header().Get("Content-Language"); got != tt.wantLanguage {
+				t.Errorf("Content-Language = %q, want %q", got, tt.wantLanguage)
+			}
+			if got := rec.Header().Get(echo.HeaderContentType); got != ProblemContentType {
+				t.Errorf("Content-Type = %q, want %q", got, ProblemContentType)
+			}
+			if problem.Title != tt.wantTitle || problem.Detail != tt.wantDetail {
+				t.Errorf("title %q, detail %q, want %q, %q",
+					problem.Title, problem.Detail, tt.wantTitle, tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestErrorHandlerLocalizesFieldErrors(t *testing.T) {
+	err := apperror.Validation(apperror.FieldError{
+		Field:   "page_size",
+		Code:    apperror.FieldOutOfRange,
+		Message: "page_size must be between 1 and 100",
+		Params:  map[string]any{"field": "page_size", "min": 1, "max": 100},
+	})
+
+	for lang, want := range map[string]string{
+		"en-US": "page_size must be between 1 and 100",
+		"zh-CN": "page_size 必须介于 1 和 100 之间",
+	} {
+		_, problem := serveError(t, err, lang)
+		if len(problem.Errors) != 1 || problem.Errors[0].Message != want {
+			t.Errorf("%s: field errors %+v, want message %q", lang, problem.Errors, want)
+		}
+	}
+}
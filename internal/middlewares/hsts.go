@@ -0,0 +1,43 @@
+package middlewares
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	config "github.com/oj-lab/reborn/configs"
+)
+
+// HeaderStrictTransportSecurity tells browsers to only use HTTPS
+const HeaderStrictTransportSecurity = "Strict-Transport-Security"
+
+// HSTS returns a middleware that sets Strict-Transport-Security on HTTPS
+// responses. Browsers ignore the header over plain HTTP, so it is not sent.
+func HSTS(cfg config.HSTSConfig) echo.MiddlewareFunc {
+	if !cfg.Enabled {
+		return func(next echo.HandlerFunc) echo.HandlerFunc {
+			return next
+		}
+	}
+
+	value := hstsValue(cfg)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Scheme() == "https" {
+				c.Response().Header().Set(HeaderStrictTransportSecurity, value)
+			}
+			return next(c)
+		}
+	}
+}
+
+func hstsValue(cfg config.HSTSConfig) string {
+	directives := []string{fmt.Sprintf("max-age=%d", int64(cfg.MaxAge.Seconds()))}
+	if cfg.IncludeSubdomains {
+		directives = append(directives, "includeSubDomains")
+	}
+	if cfg.Preload {
+		directives = append(directives, "preload")
+	}
+	return strings.Join(directives, "; ")
+}
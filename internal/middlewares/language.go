@@ -0,0 +1,19 @@
+package middlewares
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/oj-lab/reborn/internal/i18n"
+)
+
+// LanguageCookieName holds the language picked in the website, which takes
+// precedence over the browser's Accept-Language header
+const LanguageCookieName = "lang"
+
+// RequestLanguage returns the supported language best matching the request
+func RequestLanguage(c echo.Context) string {
+	var preference string
+	if cookie, err := c.Cookie(LanguageCookieName); err == nil {
+		preference = cookie.Value
+	}
+	return i18n.Negotiate(preference, c.Request().Header.Get("Accept-Language"))
+}
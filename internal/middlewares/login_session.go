@@ -1,17 +1,24 @@
 package middlewares
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/oj-lab/reborn/internal/services"
 	"github.com/oj-lab/user-service/pkg/userpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Context keys for storing user information
 const (
 	LoginSessionCookieName = "login_session"
 	UserTokenKey           = "user_token"
+	UserIDKey              = "user_id"
 )
 
 // LoginSession returns a middleware that validates login session from cookie
@@ -22,7 +29,7 @@ func LoginSession(authService *services.AuthService) echo.MiddlewareFunc {
 			// Check if auth service is available
 			if authService == nil || !authService.IsHealthy() {
 				// Log the issue for debugging
-				c.Logger().Warn("Auth service is not available or healthy")
+				slog.WarnContext(c.Request().Context(), "Auth service is not available or healthy")
 				// Continue without authentication instead of returning error
 				return next(c)
 			}
@@ -31,21 +38,21 @@ func LoginSession(authService *services.AuthService) echo.MiddlewareFunc {
 			cookie, err := c.Cookie(LoginSessionCookieName)
 			if err != nil {
 				// No session cookie found, continue without authentication
-				c.Logger().Debug("No session cookie found")
+				slog.DebugContext(c.Request().Context(), "No session cookie found")
 				return next(c)
 			}
 
 			sessionID := cookie.Value
 			if sessionID == "" {
 				// Empty session ID, continue without authentication
-				c.Logger().Debug("Empty session ID")
+				slog.DebugContext(c.Request().Context(), "Empty session ID")
 				return next(c)
 			}
 
 			// Get user token from auth service using session ID
 			client := authService.GetClient()
 			if client == nil {
-				c.Logger().Warn("Auth client is not available")
+				slog.WarnContext(c.Request().Context(), "Auth client is not available")
 				// Continue without authentication instead of returning error
 				return next(c)
 			}
@@ -55,8 +62,24 @@ func LoginSession(authService *services.AuthService) echo.MiddlewareFunc {
 					SessionId: sessionID,
 				})
 			if err != nil {
+				// Keep the cookie when the auth service is merely unreachable,
+				// the session may still be valid once it recovers
+				switch status.Code(err) {
+				case codes.Unavailable, codes.DeadlineExceeded:
+					slog.WarnContext(
+						c.Request().Context(),
+						"Auth service unavailable, skipping session validation",
+						"error", err,
+					)
+					return next(c)
+				}
+
 				// Invalid or expired session, clear cookie and continue
-				c.Logger().Debug("Failed to get user token, clearing session cookie")
+				slog.DebugContext(
+					c.Request().Context(),
+					"Failed to get user token, clearing session cookie",
+					"error", err,
+				)
 				c.SetCookie(&http.Cookie{
 					Name:   LoginSessionCookieName,
 					Path:   "/",
@@ -67,7 +90,10 @@ func LoginSession(authService *services.AuthService) echo.MiddlewareFunc {
 
 			// Store user token in context for subsequent handlers
 			c.Set(UserTokenKey, userToken.Token)
-			c.Logger().Debug("User token stored in context")
+			if userID, ok := userIDFromToken(userToken.Token); ok {
+				c.Set(UserIDKey, userID)
+			}
+			slog.DebugContext(c.Request().Context(), "User token stored in context")
 
 			return next(c)
 		}
@@ -82,6 +108,39 @@ func GetUserToken(c echo.Context) string {
 	return ""
 }
 
+// GetUserID retrieves the ID of the authenticated user from context, or 0
+func GetUserID(c echo.Context) uint64 {
+	if userID, ok := c.Get(UserIDKey).(uint64); ok {
+		return userID
+	}
+	if user := GetCurrentUser(c); user != nil {
+		return user.Id
+	}
+	return 0
+}
+
+// userIDFromToken reads the user ID claim of a token issued by the auth
+// service. The signature is not verified, the token was received from the
+// auth service itself rather than from the client.
+func userIDFromToken(token string) (uint64, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return 0, false
+	}
+
+	var claims struct {
+		UserID uint64 `json:"user_id"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.UserID == 0 {
+		return 0, false
+	}
+	return claims.UserID, true
+}
+
 // IsAuthenticated checks if the current request is authenticated
 func IsAuthenticated(c echo.Context) bool {
 	return GetUserToken(c) != ""
@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/oj-lab/reborn/internal/apperror"
+	"github.com/oj-lab/reborn/internal/metrics"
+)
+
+// unmatchedRoute labels requests that did not match a registered route, to
+// keep the cardinality of the route label bounded
+const unmatchedRoute = "unmatched"
+
+// Metrics returns a middleware that records request counts and latency by
+// route template and status code
+func Metrics() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+
+			status := c.Response().Status
+			if err != nil {
+				// The error handler has not written the response yet
+				status = apperror.StatusOf(err)
+			}
+
+			metrics.ObserveHTTPRequest(
+				c.Request().Method,
+				routeLabel(c),
+				strconv.Itoa(status),
+				time.Since(start),
+			)
+			return err
+		}
+	}
+}
+
+// routeLabel returns the route template of the request, e.g. /api/v1/user/me
+func routeLabel(c echo.Context) string {
+	if route := c.Path(); route != "" {
+		return route
+	}
+	return unmatchedRoute
+}
@@ -0,0 +1,77 @@
+package middlewares
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/oj-lab/reborn/internal/apperror"
+	"github.com/oj-lab/reborn/internal/metrics"
+	"github.com/oj-lab/reborn/internal/services"
+)
+
+// Rate limit response headers, following the IETF RateLimit header fields draft
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRateLimitPolicy    = "RateLimit-Policy"
+)
+
+// RateLimiter returns a middleware that limits requests by the named rule,
+// per user when authenticated and per client IP otherwise. It must run
+// after LoginSession to tell users apart. Requests are let through when the
+// store is unavailable.
+func RateLimiter(rateLimitService *services.RateLimitService, rule string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if rateLimitService == nil {
+				return next(c)
+			}
+
+			identity := "ip:" + c.RealIP()
+			if userID := GetUserID(c); userID != 0 {
+				identity = "user:" + strconv.FormatUint(userID, 10)
+			}
+
+			result, limited, err := rateLimitService.Allow(c.Request().Context(), rule, identity)
+			if err != nil {
+				slog.WarnContext(c.Request().Context(), "Rate limit check failed",
+					"rule", rule,
+					"error", err,
+				)
+				return next(c)
+			}
+			if !limited {
+				return next(c)
+			}
+
+			reset := strconv.Itoa(ceilSeconds(result.Reset))
+			header := c.Response().Header()
+			header.Set(HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+			header.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+			header.Set(HeaderRateLimitReset, reset)
+			header.Set(HeaderRateLimitPolicy, strconv.Itoa(result.Limit)+";w="+
+				strconv.Itoa(ceilSeconds(result.Window)))
+
+			if !result.Allowed {
+				metrics.IncRateLimitRejection(routeLabel(c))
+				header.Set(echo.HeaderRetryAfter, reset)
+				return apperror.New(
+					http.StatusTooManyRequests,
+					apperror.CodeRateLimited,
+					"Too many requests",
+				)
+			}
+			return next(c)
+		}
+	}
+}
+
+// ceilSeconds rounds d up to whole seconds, at least one
+func ceilSeconds(d time.Duration) int {
+	return max(int(math.Ceil(d.Seconds())), 1)
+}
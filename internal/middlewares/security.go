@@ -0,0 +1,78 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	config "github.com/oj-lab/reborn/configs"
+)
+
+// Security response headers
+const (
+	HeaderContentSecurityPolicy           = "Content-Security-Policy"
+	HeaderContentSecurityPolicyReportOnly = "Content-Security-Policy-Report-Only"
+	HeaderPermissionsPolicy               = "Permissions-Policy"
+)
+
+// cspNonceKey stores the CSP nonce of a request in the echo context
+const cspNonceKey = "csp_nonce"
+
+// Security returns a middleware that sets the configured security headers.
+// When the CSP contains a nonce placeholder, a fresh nonce is generated for
+// every request and made available through CSPNonce.
+func Security(cfg config.SecurityConfig) echo.MiddlewareFunc {
+	cspHeader := HeaderContentSecurityPolicy
+	if cfg.CSPReportOnly {
+		cspHeader = HeaderContentSecurityPolicyReportOnly
+	}
+	useNonce := strings.Contains(cfg.ContentSecurityPolicy, config.CSPNoncePlaceholder)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Response().Header()
+			if cfg.ContentTypeNosniff {
+				header.Set(echo.HeaderXContentTypeOptions, "nosniff")
+			}
+			if cfg.FrameOptions != "" {
+				header.Set(echo.HeaderXFrameOptions, cfg.FrameOptions)
+			}
+			if cfg.ReferrerPolicy != "" {
+				header.Set(echo.HeaderReferrerPolicy, cfg.ReferrerPolicy)
+			}
+			if cfg.PermissionsPolicy != "" {
+				header.Set(HeaderPermissionsPolicy, cfg.PermissionsPolicy)
+			}
+
+			if cfg.ContentSecurityPolicy != "" {
+				policy := cfg.ContentSecurityPolicy
+				if useNonce {
+					nonce, err := newCSPNonce()
+					if err != nil {
+						return err
+					}
+					c.Set(cspNonceKey, nonce)
+					policy = strings.ReplaceAll(policy, config.CSPNoncePlaceholder, nonce)
+				}
+				header.Set(cspHeader, policy)
+			}
+			return next(c)
+		}
+	}
+}
+
+// CSPNonce returns the nonce allowed by the Content-Security-Policy of the
+// request, or an empty string when the policy does not use one
+func CSPNonce(c echo.Context) string {
+	nonce, _ := c.Get(cspNonceKey).(string)
+	return nonce
+}
+
+func newCSPNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval bounds how often expired windows are dropped
+const sweepInterval = time.Minute
+
+type memoryWindow struct {
+	count     int64
+	expiresAt time.Time
+}
+
+// MemoryStore keeps counters in process memory. Limits are not shared
+// between replicas and reset on restart, which also makes it a stand-in for
+// Redis in development and tests.
+type MemoryStore struct {
+	mu        sync.Mutex
+	windows   map[string]*memoryWindow
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		windows: make(map[string]*memoryWindow),
+		now:     time.Now,
+	}
+}
+
+// Increment implements Store
+func (s *MemoryStore) Increment(
+	_ context.Context,
+	key string,
+	window time.Duration,
+) (int64, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	w, ok := s.windows[key]
+	if !ok || !now.Before(w.expiresAt) {
+		w = &memoryWindow{expiresAt: now.Add(window)}
+		s.windows[key] = w
+	}
+	w.count++
+	return w.count, w.expiresAt.Sub(now), nil
+}
+
+// Close implements Store
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// sweep drops expired windows so that idle clients do not leak memory
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	for key, w := range s.windows {
+		if !now.Before(w.expiresAt) {
+			delete(s.windows, key)
+		}
+	}
+	s.nextSweep = now.Add(sweepInterval)
+}
@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	config "github.com/oj-lab/reborn/configs"
+)
+
+// newTestMemoryStore returns a store whose clock only moves when advanced
+func newTestMemoryStore() (*MemoryStore, func(time.Duration)) {
+	store := NewMemoryStore()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	return store, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestMemoryStoreIncrement(t *testing.T) {
+	store, advance := newTestMemoryStore()
+	const window = time.Minute
+
+	tests := []struct {
+		name      string
+		advance   time.Duration
+		key       string
+		wantCount int64
+		wantReset time.Duration
+	}{
+		{name: "first hit", key: "a", wantCount: 1, wantReset: window},
+		{name: "same window", advance: 20 * time.Second, key: "a", wantCount: 2, wantReset: 40 * time.Second},
+		{name: "other key", key: "b", wantCount: 1, wantReset: window},
+		{name: "last instant", advance: 40*time.Second - time.Nanosecond, key: "a", wantCount: 3, wantReset: time.Nanosecond},
+		{name: "next window", advance: time.Nanosecond, key: "a", wantCount: 1, wantReset: window},
+	}
+	for _, tt := range tests {
+		advance(tt.advance)
+		count, reset, err := store.Increment(t.Context(), tt.key, window)
+		if err != nil {
+			t.Fatalf("%s: Increment: %v", tt.name, err)
+		}
+		if count != tt.wantCount || reset != tt.wantReset {
+			t.Errorf("%s: got count %d, reset %v, want %d, %v",
+				tt.name, count, reset, tt.wantCount, tt.wantReset)
+		}
+	}
+}
+
+func TestMemoryStoreSweepsExpiredWindows(t *testing.T) {
+	store, advance := newTestMemoryStore()
+
+	for _, key := range []string{"a", "b", "c"} {
+		if _, _, err := store.Increment(t.Context(), key, time.Second); err != nil {
+			t.Fatalf("Increment: %v", err)
+		}
+	}
+	advance(sweepInterval)
+	if _, _, err := store.Increment(t.Context(), "d", time.Hour); err != nil {
+		t.Fatalf("Increment: %v", err)
+	}
+
+	if len(store.windows) != 1 {
+		t.Errorf("%d windows left after sweep, want 1", len(store.windows))
+	}
+}
+
+func TestAllow(t *testing.T) {
+	store, _ := newTestMemoryStore()
+	rule := config.RateLimitRule{Requests: 2, Window: time.Minute}
+
+	wantAllowed := []bool{true, true, false}
+	wantRemaining := []int{1, 0, 0}
+	for i := range wantAllowed {
+		result, err := Allow(t.Context(), store, "key", rule)
+		if err != nil {
+			t.Fatalf("Allow: %v", err)
+		}
+		if result.Allowed != wantAllowed[i] || result.Remaining != wantRemaining[i] {
+			t.Errorf("hit %d: allowed %v, remaining %d, want %v, %d",
+				i+1, result.Allowed, result.Remaining, wantAllowed[i], wantRemaining[i])
+		}
+		if result.Limit != rule.Requests || result.Reset != rule.Window {
+			t.Errorf("hit %d: limit %d, reset %v, want %d, %v",
+				i+1, result.Limit, result.Reset, rule.Requests, rule.Window)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	config "github.com/oj-lab/reborn/configs"
+)
+
+// Store counts hits per key in fixed time windows. Implementations must be
+// safe for concurrent use.
+type Store interface {
+	// Increment records a hit for key and returns the number of hits in the
+	// current window together with the time left until it resets
+	Increment(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error)
+	// Close releases resources held by the store
+	Close() error
+}
+
+// Result describes the state of a rate limit after a hit
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Window    time.Duration
+	// Reset is the time left until the current window ends
+	Reset time.Duration
+}
+
+// Allow records a hit for key against rule
+func Allow(
+	ctx context.Context,
+	store Store,
+	key string,
+	rule config.RateLimitRule,
+) (Result, error) {
+	count, reset, err := store.Increment(ctx, key, rule.Window)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Allowed:   count <= int64(rule.Requests),
+		Limit:     rule.Requests,
+		Remaining: max(rule.Requests-int(count), 0),
+		Window:    rule.Window,
+		Reset:     reset,
+	}, nil
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// incrementScript counts a hit and starts the window when the key has no
+// expiry yet, so that counter and expiry are updated atomically
+var incrementScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+	ttl = tonumber(ARGV[1])
+end
+return {count, ttl}
+`)
+
+// RedisStore keeps counters in a Redis-protocol server, so that limits are
+// shared by all replicas and survive restarts
+type RedisStore struct {
+	client    redis.UniversalClient
+	keyPrefix string
+}
+
+// NewRedisStore creates a store using client, prefixing every key
+func NewRedisStore(client redis.UniversalClient, keyPrefix string) *RedisStore {
+	return &RedisStore{client: client, keyPrefix: keyPrefix}
+}
+
+// ServerVersion checks that the server is reachable and returns its version
+func (s *RedisStore) ServerVersion(ctx context.Context) (string, error) {
+	info, err := s.client.Info(ctx, "server").Result()
+	if err != nil {
+		return "", err
+	}
+	for line := range strings.Lines(info) {
+		if version, ok := strings.CutPrefix(strings.TrimSpace(line), "redis_version:"); ok {
+			return version, nil
+		}
+	}
+	return "", nil
+}
+
+// Increment implements Store
+func (s *RedisStore) Increment(
+	ctx context.Context,
+	key string,
+	window time.Duration,
+) (int64, time.Duration, error) {
+	values, err := incrementScript.Run(
+		ctx,
+		s.client,
+		[]string{s.keyPrefix + key},
+		window.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to increment rate limit counter: %w", err)
+	}
+	if len(values) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rate limit script result: %v", values)
+	}
+	return values[0], time.Duration(values[1]) * time.Millisecond, nil
+}
+
+// Close implements Store
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
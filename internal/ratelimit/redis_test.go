@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const testKeyPrefix = "test:ratelimit:"
+
+func newTestRedisStore(t *testing.T) (*RedisStore, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	store := NewRedisStore(redis.NewClient(&redis.Options{Addr: server.Addr()}), testKeyPrefix)
+	t.Cleanup(func() { _ = store.Close() })
+	return store, server
+}
+
+func TestRedisStoreIncrement(t *testing.T) {
+	store, server := newTestRedisStore(t)
+	const window = time.Minute
+
+	for want := int64(1); want <= 3; want++ {
+		count, reset, err := store.Increment(t.Context(), "key", window)
+		if err != nil {
+			t.Fatalf("Increment: %v", err)
+		}
+		if count != want {
+			t.Errorf("count = %d, want %d", count, want)
+		}
+		if reset != window {
+			t.Errorf("reset = %v, want %v", reset, window)
+		}
+	}
+
+	if got, err := server.Get(testKeyPrefix + "key"); err != nil || got != "3" {
+		t.Errorf("stored counter = %q, %v, want \"3\"", got, err)
+	}
+	if got := server.TTL(testKeyPrefix + "key"); got != window {
+		t.Errorf("stored TTL = %v, want %v", got, window)
+	}
+}
+
+func TestRedisStoreKeepsWindowExpiry(t *testing.T) {
+	store, server := newTestRedisStore(t)
+	const window = time.Minute
+
+	if _, _, err := store.Increment(t.Context(), "key", window); err != nil {
+		t.Fatalf("Increment: %v", err)
+	}
+	server.FastForward(20 * time.Second)
+
+	// Later hits must not extend the window
+	count, reset, err := store.Increment(t.Context(), "key", window)
+	if err != nil {
+		t.Fatalf("Increment: %v", err)
+	}
+	if count != 2 || reset != 40*time.Second {
+		t.Errorf("got count %d, reset %v, want 2, 40s", count, reset)
+	}
+
+	server.FastForward(40 * time.Second)
+	count, reset, err = store.Increment(t.Context(), "key", window)
+	if err != nil {
+		t.Fatalf("Increment: %v", err)
+	}
+	if count != 1 || reset != window {
+		t.Errorf("after the window got count %d, reset %v, want 1, %v", count, reset, window)
+	}
+}
+
+func TestRedisStoreExpiresCounterWithoutTTL(t *testing.T) {
+	store, server := newTestRedisStore(t)
+
+	// A counter left without expiry, e.g. by a crash between INCR and
+	// PEXPIRE in a non-atomic implementation, gets one again
+	if err := server.Set(testKeyPrefix+"key", "7"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	count, reset, err := store.Increment(t.Context(), "key", time.Second)
+	if err != nil {
+		t.Fatalf("Increment: %v", err)
+	}
+	if count != 8 || reset != time.Second {
+		t.Errorf("got count %d, reset %v, want 8, 1s", count, reset)
+	}
+	if got := server.TTL(testKeyPrefix + "key"); got != time.Second {
+		t.Errorf("stored TTL = %v, want 1s", got)
+	}
+}
+
+func TestRedisStoreSeparatesKeys(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+
+	for _, key := range []string{"default:ip:a", "default:ip:b", "default:ip:a"} {
+		if _, _, err := store.Increment(t.Context(), key, time.Minute); err != nil {
+			t.Fatalf("Increment: %v", err)
+		}
+	}
+	count, _, err := store.Increment(t.Context(), "default:ip:b", time.Minute)
+	if err != nil {
+		t.Fatalf("Increment: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("count = %d, want 2", count)
+	}
+}
+
+func TestRedisStoreUnavailable(t *testing.T) {
+	store, server := newTestRedisStore(t)
+	server.Close()
+
+	if _, _, err := store.Increment(t.Context(), "key", time.Minute); err == nil {
+		t.Fatal("Increment succeeded without a server")
+	}
+}
@@ -2,6 +2,7 @@ package routers
 
 import (
 	"github.com/labstack/echo/v4"
+	config "github.com/oj-lab/reborn/configs"
 	"github.com/oj-lab/reborn/internal/handlers"
 	"github.com/oj-lab/reborn/internal/middlewares"
 	"github.com/oj-lab/reborn/internal/services"
@@ -12,16 +13,28 @@ import (
 //	@title		API V1
 //	@version	1.0
 //	@BasePath	/api/v1
-func RegisterAPIv1Routes(e *echo.Echo, serviceManager *services.ServiceManager) {
+func RegisterAPIv1Routes(
+	e *echo.Echo,
+	cfg config.Config,
+	serviceManager *services.ServiceManager,
+) {
 	authService := serviceManager.GetAuthService()
+	rateLimitService := serviceManager.GetRateLimitService()
 
 	// Initialize handlers
 	userHandler := handlers.NewUserHandler(authService)
+	i18nHandler := handlers.NewI18nHandler()
 
 	baseGroup := e.Group("/api/v1")
+	baseGroup.Use(
+		middlewares.LoginSession(authService),
+		middlewares.RateLimiter(rateLimitService, config.RouteGroupAPI),
+		middlewares.BodyLimit(cfg.Server.BodyLimits[config.RouteGroupAPI]),
+	)
 	{
+		baseGroup.GET("/i18n/:lang", i18nHandler.GetMessages)
+
 		userGroup := baseGroup.Group("/user")
-		userGroup.Use(middlewares.LoginSession(authService))
 		{
 			userGroup.GET("/me", userHandler.GetCurrentUser)
 			userGroup.GET("/list", userHandler.ListUsers, middlewares.AdminOnly(authService))
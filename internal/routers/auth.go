@@ -2,15 +2,25 @@ package routers
 
 import (
 	"github.com/labstack/echo/v4"
+	config "github.com/oj-lab/reborn/configs"
 	"github.com/oj-lab/reborn/internal/handlers"
+	"github.com/oj-lab/reborn/internal/middlewares"
 	"github.com/oj-lab/reborn/internal/services"
 )
 
 // RegisterAuthRoutes registers authentication related routes
-func RegisterAuthRoutes(e *echo.Echo, serviceManager *services.ServiceManager) {
+func RegisterAuthRoutes(
+	e *echo.Echo,
+	cfg config.Config,
+	serviceManager *services.ServiceManager,
+) {
 	authHandler := handlers.NewAuthHandler(serviceManager.GetAuthService())
 
 	authGroup := e.Group("/auth")
+	authGroup.Use(
+		middlewares.RateLimiter(serviceManager.GetRateLimitService(), config.RouteGroupAuth),
+		middlewares.BodyLimit(cfg.Server.BodyLimits[config.RouteGroupAuth]),
+	)
 	{
 		authGroup.GET("/login", authHandler.Login)
 		authGroup.GET("/callback", authHandler.Callback)
@@ -0,0 +1,84 @@
+package routers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/labstack/echo/v4"
+	config "github.com/oj-lab/reborn/configs"
+	"github.com/oj-lab/reborn/internal/diagnostics"
+	"github.com/oj-lab/reborn/internal/middlewares"
+	"github.com/oj-lab/reborn/internal/services"
+	"github.com/oj-lab/reborn/internal/static"
+)
+
+const testBodyLimit = 16
+
+func bodyLimitConfig() config.Config {
+	var cfg config.Config
+	cfg.Server.BodyLimits = map[string]int64{config.RouteGroupDefault: testBodyLimit}
+	cfg.Metrics = config.MetricsConfig{Enabled: true, Path: "/metrics", BearerToken: "s3cret"}
+	cfg.Debug.ListenAddress = "127.0.0.1:0"
+	return cfg
+}
+
+func TestBodyLimitCoversEveryRoute(t *testing.T) {
+	cfg := bodyLimitConfig()
+	serviceManager := services.NewServiceManager()
+
+	e := echo.New()
+	e.HTTPErrorHandler = middlewares.ErrorHandler
+	RegisterHealthRoutes(e, cfg, serviceManager)
+	RegisterMetricsRoutes(e, cfg)
+
+	devServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(devServer.Close)
+	website := config.WebsiteConfig{ExcludedPrefixes: []string{"/health", "/metrics"}}
+	shell := static.NewShell(fstest.MapFS{}, newRuntimeConfig(website))
+	devProxy, err := static.NewDevProxy(devServer.URL, shell)
+	if err != nil {
+		t.Fatalf("NewDevProxy: %v", err)
+	}
+	e.Use(proxyDevServer(
+		static.NewSite(static.NewFiles(fstest.MapFS{}), website),
+		devProxy,
+		newPageHeads(website, publicPages),
+		cfg.Metrics.Path,
+		middlewares.BodyLimit(testBodyLimit),
+	))
+
+	debugServer := NewDebugServer(cfg, serviceManager, diagnostics.NewConnTracker())
+
+	for _, tt := range []struct {
+		handler http.Handler
+		method  string
+		target  string
+	}{
+		{e, http.MethodGet, "/health"},
+		{e, http.MethodGet, "/health/live"},
+		{e, http.MethodGet, "/metrics"},
+		{e, http.MethodPost, "/src/main.ts"},
+		{debugServer.Handler, http.MethodPost, DebugPath + "/pprof/symbol"},
+	} {
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(strings.Repeat("x", testBodyLimit+1)))
+		rec := httptest.NewRecorder()
+		tt.handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s %s: status = %d, want 413", tt.method, tt.target, rec.Code)
+		}
+	}
+
+	// Small bodies still get through to the dev server
+	req := httptest.NewRequest(http.MethodPost, "/src/main.ts", strings.NewReader("x"))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("small body: status = %d, want 204", rec.Code)
+	}
+}
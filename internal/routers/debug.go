@@ -0,0 +1,88 @@
+package routers
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	config "github.com/oj-lab/reborn/configs"
+	"github.com/oj-lab/reborn/internal/diagnostics"
+	"github.com/oj-lab/reborn/internal/handlers"
+	"github.com/oj-lab/reborn/internal/logging"
+	"github.com/oj-lab/reborn/internal/middlewares"
+	"github.com/oj-lab/reborn/internal/services"
+)
+
+// DebugPath prefixes the runtime diagnostics endpoints
+const DebugPath = "/admin/debug"
+
+// RegisterDebugRoutes serves runtime diagnostics to admins, unless they are
+// disabled
+func RegisterDebugRoutes(
+	e *echo.Echo,
+	cfg config.Config,
+	serviceManager *services.ServiceManager,
+	connTracker *diagnostics.ConnTracker,
+) {
+	if !cfg.Debug.Enabled {
+		return
+	}
+	authService := serviceManager.GetAuthService()
+
+	debugGroup := e.Group(DebugPath)
+	debugGroup.Use(
+		middlewares.LoginSession(authService),
+		middlewares.AdminOnly(authService),
+		middlewares.BodyLimit(cfg.Server.BodyLimits[config.RouteGroupDefault]),
+	)
+	registerDebugHandlers(debugGroup, handlers.NewDebugHandler(serviceManager, connTracker))
+}
+
+// NewDebugServer serves runtime diagnostics without authentication on
+// debug.listen_address, which is only reachable from the host
+func NewDebugServer(
+	cfg config.Config,
+	serviceManager *services.ServiceManager,
+	connTracker *diagnostics.ConnTracker,
+) *http.Server {
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.HTTPErrorHandler = middlewares.ErrorHandler
+	e.Use(
+		middlewares.Recover(),
+		middlewares.BodyLimit(cfg.Server.BodyLimits[config.RouteGroupDefault]),
+	)
+
+	registerDebugHandlers(e.Group(DebugPath), handlers.NewDebugHandler(serviceManager, connTracker))
+	return &http.Server{
+		Addr:              cfg.Debug.ListenAddress,
+		Handler:           e,
+		ReadHeaderTimeout: 10 * time.Second,
+		ErrorLog:          logging.ServerErrorLog(),
+	}
+}
+
+func registerDebugHandlers(g *echo.Group, debugHandler *handlers.DebugHandler) {
+	g.GET("/goroutines", debugHandler.Goroutines)
+	g.GET("/config", debugHandler.Config)
+	g.GET("/build", debugHandler.Build)
+	g.GET("/connections", debugHandler.Connections)
+
+	// pprof.Index only serves named profiles under /debug/pprof/, so they
+	// are routed explicitly
+	g.GET("/pprof", func(c echo.Context) error {
+		return c.Redirect(http.StatusMovedPermanently, DebugPath+"/pprof/")
+	})
+	g.GET("/pprof/", echo.WrapHandler(http.HandlerFunc(pprof.Index)))
+	g.GET("/pprof/cmdline", echo.WrapHandler(http.HandlerFunc(pprof.Cmdline)))
+	g.GET("/pprof/profile", echo.WrapHandler(http.HandlerFunc(pprof.Profile)))
+	g.GET("/pprof/symbol", echo.WrapHandler(http.HandlerFunc(pprof.Symbol)))
+	g.POST("/pprof/symbol", echo.WrapHandler(http.HandlerFunc(pprof.Symbol)))
+	g.GET("/pprof/trace", echo.WrapHandler(http.HandlerFunc(pprof.Trace)))
+	g.GET("/pprof/:name", func(c echo.Context) error {
+		pprof.Handler(c.Param("name")).ServeHTTP(c.Response(), c.Request())
+		return nil
+	})
+}
@@ -0,0 +1,37 @@
+package routers
+
+import (
+	"github.com/labstack/echo/v4"
+	config "github.com/oj-lab/reborn/configs"
+	"github.com/oj-lab/reborn/internal/handlers"
+	"github.com/oj-lab/reborn/internal/middlewares"
+	"github.com/oj-lab/reborn/internal/services"
+)
+
+// RegisterHealthRoutes registers the probes used by orchestrators, which are
+// not rate limited, and admin-only health details
+func RegisterHealthRoutes(
+	e *echo.Echo,
+	cfg config.Config,
+	serviceManager *services.ServiceManager,
+) {
+	authService := serviceManager.GetAuthService()
+	healthHandler := handlers.NewHealthHandler(serviceManager)
+	bodyLimit := middlewares.BodyLimit(cfg.Server.BodyLimits[config.RouteGroupDefault])
+
+	// Kept for existing probes, same as /health/ready
+	e.GET("/health", healthHandler.Ready, bodyLimit)
+
+	healthGroup := e.Group("/health")
+	healthGroup.Use(bodyLimit)
+	{
+		healthGroup.GET("/live", healthHandler.Live)
+		healthGroup.GET("/ready", healthHandler.Ready)
+		healthGroup.GET(
+			"/details",
+			healthHandler.Details,
+			middlewares.LoginSession(authService),
+			middlewares.AdminOnly(authService),
+		)
+	}
+}
@@ -0,0 +1,21 @@
+package routers
+
+import (
+	"github.com/labstack/echo/v4"
+	config "github.com/oj-lab/reborn/configs"
+	"github.com/oj-lab/reborn/internal/metrics"
+	"github.com/oj-lab/reborn/internal/middlewares"
+)
+
+// RegisterMetricsRoutes exposes Prometheus metrics on the main server,
+// unless they are disabled or served on a separate listener
+func RegisterMetricsRoutes(e *echo.Echo, cfg config.Config) {
+	if !cfg.Metrics.Enabled || cfg.Metrics.ListenAddress != "" {
+		return
+	}
+	e.GET(
+		cfg.Metrics.Path,
+		echo.WrapHandler(metrics.Handler(cfg.Metrics.BearerToken)),
+		middlewares.BodyLimit(cfg.Server.BodyLimits[config.RouteGroupDefault]),
+	)
+}
@@ -1,47 +1,79 @@
 package routers
 
 import (
-	"io"
+	"encoding/json"
+	"io/fs"
 	"log/slog"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/labstack/echo/v4"
 	config "github.com/oj-lab/reborn/configs"
+	"github.com/oj-lab/reborn/internal/apperror"
+	"github.com/oj-lab/reborn/internal/metrics"
 	"github.com/oj-lab/reborn/internal/middlewares"
 	"github.com/oj-lab/reborn/internal/services"
+	"github.com/oj-lab/reborn/internal/static"
+	"github.com/oj-lab/reborn/internal/version"
 )
 
 // RegisterPageRoutes registers all page routes including:
 // - Home page (/) - no authentication required
 // - Admin pages (/admin/*) - admin authentication required
 // - Static file serving for other routes (assets, etc.)
-func RegisterPageRoutes(e *echo.Echo, serviceManager *services.ServiceManager) {
+//
+// With website.dev_proxy_url set, pages and files come from the frontend dev
+// server instead of the build.
+func RegisterPageRoutes(
+	e *echo.Echo,
+	cfg config.Config,
+	serviceManager *services.ServiceManager,
+) error {
 	authService := serviceManager.GetAuthService()
-	cfg := config.Load()
+	rateLimit := middlewares.RateLimiter(
+		serviceManager.GetRateLimitService(),
+		config.RouteGroupDefault,
+	)
+	bodyLimit := middlewares.BodyLimit(cfg.Server.BodyLimits[config.RouteGroupDefault])
+	var dist fs.FS
+	if cfg.Website.DevProxyURL == "" {
+		dist, _ = static.OpenDist(cfg.Website.DistPath)
+	}
+	site := static.NewSite(static.NewFiles(dist), cfg.Website)
+	shell := static.NewShell(dist, newRuntimeConfig(cfg.Website))
+	heads := newPageHeads(cfg.Website, publicPages)
+
+	var devProxy *static.DevProxy
+	if cfg.Website.DevProxyURL != "" {
+		var err error
+		devProxy, err = static.NewDevProxy(cfg.Website.DevProxyURL, shell)
+		if err != nil {
+			return err
+		}
+		slog.Warn("Proxying website to dev server", "url", devProxy.Target().String())
+	}
 
 	// Register home page routes (no authentication required)
 	homeHandler := func(c echo.Context) error {
 		// Serve the index.html file for home page
-		indexPath := filepath.Join(cfg.Website.DistPath, "index.html")
-		return serveIndexFile(c, indexPath)
+		return serveIndexFile(c, shell, heads, devProxy)
 	}
 
 	// Home page routes
-	e.GET("/", homeHandler)
+	e.GET("/", homeHandler, rateLimit, bodyLimit)
+
+	// Search engine files
+	e.GET(robotsPath, heads.Robots, rateLimit, bodyLimit)
+	e.GET(sitemapPath, heads.Sitemap, rateLimit, bodyLimit)
 
 	// Register admin page routes with authentication
 	adminPageGroup := e.Group("/admin")
-	adminPageGroup.Use(middlewares.LoginSession(authService))
+	adminPageGroup.Use(middlewares.LoginSession(authService), rateLimit, bodyLimit)
 
 	// Admin route handler that serves the frontend index.html
 	adminHandler := func(c echo.Context) error {
 		// Serve the index.html file for all admin routes
 		// The frontend router will handle the specific admin pages
-		indexPath := filepath.Join(cfg.Website.DistPath, "index.html")
-		return serveIndexFile(c, indexPath)
+		return serveIndexFile(c, shell, heads, devProxy)
 	}
 
 	// Custom middleware to handle admin authentication for page routes
@@ -66,7 +98,7 @@ func RegisterPageRoutes(e *echo.Echo, serviceManager *services.ServiceManager) {
 
 			if err := adminHandler(c); err != nil {
 				// For admin access denied, redirect to home page
-				if he, ok := err.(*echo.HTTPError); ok && he.Code == http.StatusForbidden {
+				if apperror.StatusOf(err) == http.StatusForbidden {
 					return c.Redirect(http.StatusFound, "/?error=access_denied")
 				}
 				// For other errors (like service unavailable), redirect to login
@@ -87,121 +119,196 @@ func RegisterPageRoutes(e *echo.Echo, serviceManager *services.ServiceManager) {
 	adminPageGroup.GET("/*", adminHandler)
 
 	// Register static file serving middleware for other routes
-	e.Use(serveStaticFiles(cfg.Website.DistPath))
+	if devProxy != nil {
+		e.Use(proxyDevServer(site, devProxy, heads, cfg.Metrics.Path, bodyLimit))
+		return nil
+	}
+	e.Use(serveStaticFiles(site, shell, heads, cfg.Metrics.Path, rateLimit, bodyLimit))
+	return nil
 }
 
-// serveIndexFile serves the index.html file for SPA routing
-func serveIndexFile(c echo.Context, filePath string) error {
-	file, err := os.Open(filePath)
+// serveIndexFile serves the index.html shell for SPA routing with the head
+// of the requested page, allowing its scripts and styles through the
+// Content-Security-Policy nonce
+func serveIndexFile(
+	c echo.Context,
+	shell *static.Shell,
+	heads *pageHeads,
+	devProxy *static.DevProxy,
+) error {
+	if devProxy != nil {
+		return serveDevServer(c, devProxy, heads)
+	}
+
+	html, err := shell.Render(heads.Head(c), middlewares.CSPNonce(c))
 	if err != nil {
 		return echo.NewHTTPError(http.StatusNotFound, "Admin page not found")
 	}
-	defer func() {
-		if closeErr := file.Close(); closeErr != nil {
-			slog.ErrorContext(c.Request().Context(), "Failed to close file",
-				"file", filePath,
-				"error", closeErr)
-		}
-	}()
 
 	// Set content type for HTML
 	c.Response().Header().Set("Content-Type", "text/html; charset=utf-8")
 	c.Response().Header().Set("Cache-Control", "no-cache")
 
 	c.Response().WriteHeader(http.StatusOK)
-	_, err = io.Copy(c.Response().Writer, file)
+	_, err = c.Response().Write(html)
 	return err
 }
 
-// serveStaticFiles serves static files from the dist directory (similar to StaticWebsite middleware)
-func serveStaticFiles(distPath string) echo.MiddlewareFunc {
+// runtimeConfig is the server configuration the frontend boots with
+type runtimeConfig struct {
+	Title          string          `json:"title"`
+	PublicURL      string          `json:"public_url"`
+	LoginProviders []string        `json:"login_providers"`
+	Features       map[string]bool `json:"features"`
+	Version        string          `json:"version"`
+}
+
+// newRuntimeConfig returns the JSON runtime configuration of the frontend
+func newRuntimeConfig(cfg config.WebsiteConfig) []byte {
+	rc := runtimeConfig{
+		Title:          cfg.Title,
+		PublicURL:      cfg.PublicURL,
+		LoginProviders: cfg.LoginProviders,
+		Features:       cfg.Features,
+		Version:        version.Get().Version,
+	}
+	if rc.LoginProviders == nil {
+		rc.LoginProviders = []string{}
+	}
+	if rc.Features == nil {
+		rc.Features = map[string]bool{}
+	}
+	// Marshaling strings, slices and maps of them cannot fail. Angle
+	// brackets are escaped, so values cannot close the script tag.
+	data, _ := json.Marshal(rc)
+	return data
+}
+
+// serveStaticFiles answers the remaining GET requests from the website
+// build, with the SPA shell for client-side routes. Hashed assets are not
+// rate limited: a page load requests dozens of them, all from the client IP
+// since no session is loaded here, and browsers cache them forever.
+func serveStaticFiles(
+	site *static.Site,
+	shell *static.Shell,
+	heads *pageHeads,
+	metricsPath string,
+	rateLimit echo.MiddlewareFunc,
+	bodyLimit echo.MiddlewareFunc,
+) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			path := c.Request().URL.Path
 
-			// Skip for API routes, auth routes, admin routes (handled above), and health
-			if strings.HasPrefix(path, "/api/") ||
-				strings.HasPrefix(path, "/auth/") ||
-				strings.HasPrefix(path, "/admin/") ||
-				strings.HasPrefix(path, "/health") ||
-				path == "/" { // Home page is handled above
+			// The home page and search engine files are handled above,
+			// metrics by their own route
+			if path == "/" || path == robotsPath || path == sitemapPath ||
+				(metricsPath != "" && path == metricsPath) {
 				return next(c)
 			}
 
-			// Try to serve static file
-			filePath := filepath.Join(distPath, path)
-
-			// Check if file exists
-			if info, err := os.Stat(filePath); err == nil && !info.IsDir() {
-				return serveStaticFile(c, filePath)
+			resolution, name, err := site.Resolve(path)
+			if err == nil && resolution == static.ResolveSkip {
+				// Other routes apply their own limits
+				return next(c)
 			}
-
-			// If it's a directory, try index.html
-			if info, err := os.Stat(filePath); err == nil && info.IsDir() {
-				indexPath := filepath.Join(filePath, "index.html")
-				if _, err := os.Stat(indexPath); err == nil {
-					return serveStaticFile(c, indexPath)
-				}
+			serve := func(c echo.Context) error {
+				return serveSiteFile(c, site, shell, heads, resolution, name, err, next)
 			}
-
-			// For SPA routing, serve index.html for unknown routes
-			indexPath := filepath.Join(distPath, "index.html")
-			if _, err := os.Stat(indexPath); err == nil {
-				return serveIndexFile(c, indexPath)
+			if err == nil && resolution == static.ResolveFile && site.Immutable(name) {
+				return bodyLimit(serve)(c)
 			}
-
-			return next(c)
+			return rateLimit(bodyLimit(serve))(c)
 		}
 	}
 }
 
-// serveStaticFile serves a static file with appropriate content type
-func serveStaticFile(c echo.Context, filePath string) error {
-	file, err := os.Open(filePath)
+// serveSiteFile answers a request the way its path resolved in the website
+// build
+func serveSiteFile(
+	c echo.Context,
+	site *static.Site,
+	shell *static.Shell,
+	heads *pageHeads,
+	resolution static.Resolution,
+	name string,
+	err error,
+	next echo.HandlerFunc,
+) error {
 	if err != nil {
-		return err
+		return apperror.New(http.StatusBadRequest, apperror.CodeBadRequest, "Invalid path").
+			WithInternal(err)
 	}
-	defer func() {
-		if closeErr := file.Close(); closeErr != nil {
-			slog.ErrorContext(c.Request().Context(), "Failed to close file",
-				"file", filePath,
-				"error", closeErr)
-		}
-	}()
-
-	// Set content type based on file extension
-	ext := filepath.Ext(filePath)
-	switch ext {
-	case ".html":
-		c.Response().Header().Set("Content-Type", "text/html; charset=utf-8")
-	case ".css":
-		c.Response().Header().Set("Content-Type", "text/css; charset=utf-8")
-	case ".js":
-		c.Response().Header().Set("Content-Type", "application/javascript; charset=utf-8")
-	case ".json":
-		c.Response().Header().Set("Content-Type", "application/json; charset=utf-8")
-	case ".png":
-		c.Response().Header().Set("Content-Type", "image/png")
-	case ".jpg", ".jpeg":
-		c.Response().Header().Set("Content-Type", "image/jpeg")
-	case ".gif":
-		c.Response().Header().Set("Content-Type", "image/gif")
-	case ".svg":
-		c.Response().Header().Set("Content-Type", "image/svg+xml")
-	case ".ico":
-		c.Response().Header().Set("Content-Type", "image/x-icon")
+
+	switch resolution {
+	case static.ResolveFile:
+		return serveStaticFile(c, site, name)
+	case static.ResolveIndex:
+		metrics.IncStaticFileHit(metrics.StaticKindSPAFallback)
+		return serveIndexFile(c, shell, heads, nil)
+	case static.ResolveNotFound:
+		metrics.IncStaticFileHit(metrics.StaticKindMissing)
+		return echo.ErrNotFound
 	default:
-		c.Response().Header().Set("Content-Type", "application/octet-stream")
+		return next(c)
 	}
+}
 
-	// Set cache headers for static assets
-	if strings.Contains(filePath, "/assets/") {
-		c.Response().Header().Set("Cache-Control", "public, max-age=31536000") // 1 year
+// serveStaticFile serves a file of the website build with validators, so
+// that browsers can revalidate or request ranges
+func serveStaticFile(c echo.Context, site *static.Site, name string) error {
+	// Hashed assets never change, everything else is revalidated
+	if site.Immutable(name) {
+		c.Response().Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		metrics.IncStaticFileHit(metrics.StaticKindAsset)
 	} else {
 		c.Response().Header().Set("Cache-Control", "no-cache")
+		metrics.IncStaticFileHit(metrics.StaticKindFile)
 	}
+	return site.Files().Serve(c.Response(), c.Request(), name)
+}
 
-	c.Response().WriteHeader(http.StatusOK)
-	_, err = io.Copy(c.Response().Writer, file)
-	return err
+// proxyDevServer forwards the requests that the build would answer to the
+// frontend dev server. Modules are requested by the hundreds on page load,
+// so they bypass the rate limit.
+func proxyDevServer(
+	site *static.Site,
+	devProxy *static.DevProxy,
+	heads *pageHeads,
+	metricsPath string,
+	bodyLimit echo.MiddlewareFunc,
+) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			path := c.Request().URL.Path
+
+			// Same routes as serveStaticFiles, backend paths keep their
+			// guards
+			if path == "/" || path == robotsPath || path == sitemapPath ||
+				(metricsPath != "" && path == metricsPath) || site.Excluded(path) {
+				return next(c)
+			}
+			if _, err := static.CleanPath(path); err != nil {
+				return apperror.New(http.StatusBadRequest, apperror.CodeBadRequest, "Invalid path").
+					WithInternal(err)
+			}
+
+			return bodyLimit(func(c echo.Context) error {
+				return serveDevServer(c, devProxy, heads)
+			})(c)
+		}
+	}
+}
+
+// serveDevServer proxies a request to the frontend dev server
+func serveDevServer(c echo.Context, devProxy *static.DevProxy, heads *pageHeads) error {
+	err := devProxy.Serve(c.Response(), c.Request(), heads.Head(c), middlewares.CSPNonce(c))
+	if err != nil {
+		return apperror.New(
+			http.StatusBadGateway,
+			apperror.CodeServiceUnavailable,
+			"Frontend dev server unavailable",
+		).WithInternal(err)
+	}
+	return nil
 }
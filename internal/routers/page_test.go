@@ -0,0 +1,71 @@
+package routers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/labstack/echo/v4"
+	config "github.com/oj-lab/reborn/configs"
+	"github.com/oj-lab/reborn/internal/middlewares"
+	"github.com/oj-lab/reborn/internal/static"
+)
+
+func TestServeStaticFiles(t *testing.T) {
+	dist := fstest.MapFS{
+		static.IndexFile:      {Data: []byte(`<html><head><title>Reborn</title></head><body></body></html>`)},
+		"assets/index-abc.js": {Data: []byte("console.log(1)")},
+		"favicon.ico":         {Data: []byte("icon")},
+	}
+	cfg := config.WebsiteConfig{
+		Title:            "Reborn",
+		ExcludedPrefixes: []string{"/api/", "/auth/", "/admin/", "/health"},
+		AssetPrefixes:    []string{"/assets/"},
+	}
+	passthrough := func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+
+	e := echo.New()
+	e.HTTPErrorHandler = middlewares.ErrorHandler
+	e.Use(serveStaticFiles(
+		static.NewSite(static.NewFiles(dist), cfg),
+		static.NewShell(dist, newRuntimeConfig(cfg)),
+		newPageHeads(cfg, publicPages),
+		"/metrics",
+		passthrough,
+		passthrough,
+	))
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantType   string
+		wantBody   string
+	}{
+		{name: "asset", target: "/assets/index-abc.js", wantStatus: http.StatusOK,
+			wantType: "text/javascript", wantBody: "console.log(1)"},
+		{name: "missing asset", target: "/assets/index-old.js", wantStatus: http.StatusNotFound},
+		{name: "client-side route", target: "/problems/42", wantStatus: http.StatusOK,
+			wantType: "text/html", wantBody: "window.__REBORN_CONFIG__"},
+		{name: "encoded traversal", target: "/%2e%2e/etc/passwd", wantStatus: http.StatusBadRequest},
+		{name: "excluded prefix", target: "/api/v1/missing", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get(echo.HeaderContentType); !strings.HasPrefix(got, tt.wantType) {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body %q does not contain %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package routers
+
+import (
+	"encoding/xml"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	config "github.com/oj-lab/reborn/configs"
+	"github.com/oj-lab/reborn/internal/apperror"
+	"github.com/oj-lab/reborn/internal/static"
+)
+
+// Paths of the search engine files
+const (
+	robotsPath  = "/robots.txt"
+	sitemapPath = "/sitemap.xml"
+)
+
+// headResolver fills the head of a public page from backend data, given
+// the values of the :params of its pattern
+type headResolver func(c echo.Context, params map[string]string, head *static.Head) error
+
+// publicPage is a route of the SPA that link previews and search engines
+// may see
+type publicPage struct {
+	// pattern is a path whose ":name" segments match any value
+	pattern string
+	// resolve is nil for pages described by the site defaults
+	resolve headResolver
+}
+
+// publicPages lists the public routes of the SPA. Pages backed by data,
+// such as problems, contests or profiles, add a resolver along with their
+// route in the frontend once Reborn can read that data for anonymous
+// visitors.
+var publicPages = []publicPage{
+	{pattern: "/"},
+}
+
+// pageHeads renders route-aware document heads and the search engine files
+type pageHeads struct {
+	cfg   config.WebsiteConfig
+	pages []publicPage
+}
+
+func newPageHeads(cfg config.WebsiteConfig, pages []publicPage) *pageHeads {
+	return &pageHeads{cfg: cfg, pages: pages}
+}
+
+// Head returns the head of the requested page. Resolver errors keep the
+// site defaults, so that pages render even when backends are down. Without
+// a public URL, the head has no canonical URL and drops relative images.
+func (h *pageHeads) Head(c echo.Context) static.Head {
+	base := h.baseURL()
+	head := static.Head{
+		Title:       h.cfg.Title,
+		Description: h.cfg.Description,
+		SiteName:    h.cfg.Title,
+		Image:       absoluteURL(base, h.cfg.Image),
+	}
+
+	urlPath := c.Request().URL.Path
+	for _, page := range h.pages {
+		params, ok := matchPattern(page.pattern, urlPath)
+		if !ok {
+			continue
+		}
+		if base != "" {
+			head.URL = base + urlPath
+		}
+		if page.resolve != nil {
+			resolved := head
+			if err := page.resolve(c, params, &resolved); err != nil {
+				slog.WarnContext(c.Request().Context(), "Failed to resolve page head",
+					"path", urlPath, "error", err)
+			} else {
+				head = resolved
+			}
+		}
+		break
+	}
+	return head
+}
+
+// Robots serves robots.txt, keeping crawlers away from backend paths and
+// pointing them to the sitemap when there is one
+func (h *pageHeads) Robots(c echo.Context) error {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	for _, prefix := range h.cfg.ExcludedPrefixes {
+		b.WriteString("Disallow: " + prefix + "\n")
+	}
+	if base := h.baseURL(); base != "" {
+		b.WriteString("\nSitemap: " + base + sitemapPath + "\n")
+	}
+	return c.String(http.StatusOK, b.String())
+}
+
+// sitemapURLSet is the root element of a sitemap
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc string `xml:"loc"`
+}
+
+// Sitemap serves sitemap.xml with the public pages that have a fixed path.
+// It needs a public URL, since its locations must be absolute.
+func (h *pageHeads) Sitemap(c echo.Context) error {
+	base := h.baseURL()
+	if base == "" {
+		return apperror.New(http.StatusNotFound, apperror.CodeNotFound, "Sitemap requires website.public_url")
+	}
+	urlSet := sitemapURLSet{}
+	for _, page := range h.pages {
+		if !strings.Contains(page.pattern, "/:") {
+			urlSet.URLs = append(urlSet.URLs, sitemapURL{Loc: base + page.pattern})
+		}
+	}
+
+	data, err := xml.Marshal(urlSet)
+	if err != nil {
+		return err
+	}
+	return c.Blob(http.StatusOK, echo.MIMEApplicationXMLCharsetUTF8,
+		append([]byte(xml.Header), data...))
+}
+
+// baseURL returns the configured public URL of the site. The request host
+// is never used instead: clients choose it, and a poisoned one would end up
+// in cached pages.
+func (h *pageHeads) baseURL() string {
+	return strings.TrimSuffix(h.cfg.PublicURL, "/")
+}
+
+// absoluteURL resolves a path against base, keeping absolute URLs. Paths
+// resolve to nothing without a base.
+func absoluteURL(base, ref string) string {
+	if ref == "" || strings.Contains(ref, "://") {
+		return ref
+	}
+	if base == "" {
+		return ""
+	}
+	return base + "/" + strings.TrimPrefix(ref, "/")
+}
+
+// matchPattern reports whether urlPath matches pattern, returning the
+// values of its ":name" segments
+func matchPattern(pattern, urlPath string) (map[string]string, bool) {
+	patternSegments := strings.Split(pattern, "/")
+	pathSegments := strings.Split(urlPath, "/")
+	if len(patternSegments) != len(pathSegments) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, segment := range patternSegments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok && pathSegments[i] != "" {
+			params[name] = pathSegments[i]
+		} else if segment != pathSegments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
@@ -0,0 +1,111 @@
+package routers
+
+import (
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	config "github.com/oj-lab/reborn/configs"
+	"github.com/oj-lab/reborn/internal/middlewares"
+	"github.com/oj-lab/reborn/internal/static"
+)
+
+var testWebsite = config.WebsiteConfig{
+	Title:            "OJ Lab",
+	Description:      "An online judge",
+	Image:            "/og.png",
+	ExcludedPrefixes: []string{"/api/", "/admin/"},
+}
+
+// headOf resolves the head of target, requested with a forged Host header
+func headOf(heads *pageHeads, target string) static.Head {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Host = "evil.example"
+	return heads.Head(echo.New().NewContext(req, httptest.NewRecorder()))
+}
+
+func TestPageHeadsIgnoreRequestHost(t *testing.T) {
+	heads := newPageHeads(testWebsite, publicPages)
+
+	head := headOf(heads, "/")
+	if head.URL != "" || head.Image != "" {
+		t.Errorf("URL %q, image %q without a public URL, want none", head.URL, head.Image)
+	}
+
+	e := echo.New()
+	e.HTTPErrorHandler = middlewares.ErrorHandler
+	e.GET(robotsPath, heads.Robots)
+	e.GET(sitemapPath, heads.Sitemap)
+	for target, wantStatus := range map[string]int{
+		robotsPath:  http.StatusOK,
+		sitemapPath: http.StatusNotFound,
+	} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Host = "evil.example"
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		if rec.Code != wantStatus {
+			t.Errorf("%s: status = %d, want %d", target, rec.Code, wantStatus)
+		}
+		if body := rec.Body.String(); strings.Contains(body, "evil.example") || strings.Contains(body, "Sitemap:") {
+			t.Errorf("%s: body %q points to a sitemap", target, body)
+		}
+	}
+}
+
+func TestPageHeadsWithPublicURL(t *testing.T) {
+	cfg := testWebsite
+	cfg.PublicURL = "https://oj.example.com"
+	heads := newPageHeads(cfg, publicPages)
+
+	if head := headOf(heads, "/"); head.Image != "https://oj.example.com/og.png" {
+		t.Errorf("image = %q, want it resolved against the public URL", head.Image)
+	}
+
+	e := echo.New()
+	for target, want := range map[string]string{
+		robotsPath:  "Sitemap: https://oj.example.com/sitemap.xml",
+		sitemapPath: "<loc>https://oj.example.com/</loc>",
+	} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		handler := heads.Robots
+		if target == sitemapPath {
+			handler = heads.Sitemap
+		}
+		if err := handler(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("%s: body %q does not contain %q", target, rec.Body.String(), want)
+		}
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          map[string]string
+		wantOK        bool
+	}{
+		{pattern: "/", path: "/", want: map[string]string{}, wantOK: true},
+		{pattern: "/users/:id", path: "/users/7", want: map[string]string{"id": "7"}, wantOK: true},
+		{pattern: "/users/:id", path: "/users/", wantOK: false},
+		{pattern: "/users/:id", path: "/users/7/edit", wantOK: false},
+		{pattern: "/", path: "/users", wantOK: false},
+	}
+	for _, tt := range tests {
+		params, ok := matchPattern(tt.pattern, tt.path)
+		if ok != tt.wantOK {
+			t.Errorf("matchPattern(%q, %q) ok = %v, want %v", tt.pattern, tt.path, ok, tt.wantOK)
+			continue
+		}
+		if ok && !maps.Equal(params, tt.want) {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", tt.pattern, tt.path, params, tt.want)
+		}
+	}
+}
@@ -1,26 +1,75 @@
 package services
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	config "github.com/oj-lab/reborn/configs"
 	"github.com/oj-lab/reborn/internal/client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 )
 
+const (
+	defaultHealthCheckInterval = 10 * time.Second
+	defaultHealthCheckTimeout  = 2 * time.Second
+	// reconnectDrainTimeout bounds how long in-flight calls may keep the old
+	// connection open after a reconnect
+	reconnectDrainTimeout = 10 * time.Second
+)
+
+// AuthServiceName identifies the auth service in the ServiceManager
+const AuthServiceName = "auth_service"
+
 // AuthService manages auth service client connections
 type AuthService struct {
 	client *client.AuthServiceClient
 	mu     sync.RWMutex
+	// closed rejects reconnects racing with Stop
+	closed bool
+	// reloadMu serializes ApplyConfig, which restarts the health probe
+	reloadMu sync.Mutex
+
+	healthInterval time.Duration
+	healthTimeout  time.Duration
+	health         HealthStatus
+	healthMu       sync.RWMutex
+	stopProbe      chan struct{}
+	probeDone      chan struct{}
 }
 
 // NewAuthService creates a new AuthService instance
 func NewAuthService() *AuthService {
-	return &AuthService{}
+	return &AuthService{
+		healthInterval: defaultHealthCheckInterval,
+		healthTimeout:  defaultHealthCheckTimeout,
+	}
+}
+
+// Name implements Service
+func (s *AuthService) Name() string {
+	return AuthServiceName
+}
+
+// Dependencies implements Service
+func (s *AuthService) Dependencies() []string {
+	return nil
 }
 
-// Initialize sets up the auth service client with provided config
-func (s *AuthService) Initialize(cfg config.AuthServiceConfig) error {
+// Start sets up the auth service client with provided config
+// and starts probing its health in the background
+func (s *AuthService) Start(_ context.Context, appCfg config.Config) error {
+	cfg := appCfg.AuthService
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -30,6 +79,18 @@ func (s *AuthService) Initialize(cfg config.AuthServiceConfig) error {
 	}
 
 	s.client = client
+	s.closed = false
+	if cfg.HealthCheckInterval > 0 {
+		s.healthInterval = cfg.HealthCheckInterval
+	}
+	if cfg.HealthCheckTimeout > 0 {
+		s.healthTimeout = cfg.HealthCheckTimeout
+	}
+
+	// Probe once synchronously so the first requests see a real status
+	client.Connect()
+	s.recordHealth(s.checkHealth(client, s.healthTimeout))
+	s.startProbe(s.healthInterval, s.healthTimeout)
 	return nil
 }
 
@@ -48,49 +109,301 @@ func (s *AuthService) SetClient(client *client.AuthServiceClient) {
 	// Close existing client if any
 	if s.client != nil {
 		if err := s.client.Close(); err != nil {
-			log.Printf("Error closing existing auth service connection: %v", err)
+			slog.Error("Error closing existing auth service connection", "error", err)
 		}
 	}
 
 	s.client = client
 }
 
-// Reconnect attempts to reconnect to the auth service
+// Reconnect swaps in a client built from cfg, then drains in-flight calls on
+// the previous connection before closing it. The old client keeps serving
+// if the new one cannot be created.
 func (s *AuthService) Reconnect(cfg *config.AuthServiceConfig) error {
+	newClient, err := client.NewAuthServiceClient(*cfg)
+	if err != nil {
+		return err
+	}
+	newClient.Connect()
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		_ = newClient.Close()
+		return errors.New("auth service is stopped")
+	}
+	oldClient := s.client
+	s.client = newClient
+	s.mu.Unlock()
+
+	slog.Info("Auth service client switched", "target", describeTarget(*cfg))
+	_, timeout := s.healthSettings()
+	s.recordHealth(s.checkHealth(newClient, timeout))
+
+	if oldClient == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), reconnectDrainTimeout)
+	defer cancel()
+	if err := oldClient.Drain(ctx); err != nil {
+		slog.Warn("Closing previous auth service connection before drain completed", "error", err)
+	}
+	if err := oldClient.Close(); err != nil {
+		slog.Error("Error closing auth service connection during reconnect", "error", err)
+	}
+	slog.Info("Previous auth service connection closed",
+		"target", describeTarget(oldClient.Config()))
+	return nil
+}
+
+// ApplyConfig applies changed health check settings in place, and reconnects
+// when any setting baked into the client changed: addresses, balancing, TLS,
+// timeouts, retries or the circuit breaker
+func (s *AuthService) ApplyConfig(_ context.Context, appCfg config.Config) error {
+	cfg := appCfg.AuthService
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	s.applyHealthCheck(cfg)
+
+	current := s.GetClient()
+	if current != nil && !clientChanged(current.Config(), cfg) {
+		return nil
+	}
+
+	slog.Info("Auth service settings changed, reconnecting", "target", describeTarget(cfg))
+	return s.Reconnect(&cfg)
+}
+
+// applyHealthCheck restarts the health probe when its interval or timeout
+// changed
+func (s *AuthService) applyHealthCheck(cfg config.AuthServiceConfig) {
+	currentInterval, currentTimeout := s.healthSettings()
+	interval, timeout := currentInterval, currentTimeout
+	if cfg.HealthCheckInterval > 0 {
+		interval = cfg.HealthCheckInterval
+	}
+	if cfg.HealthCheckTimeout > 0 {
+		timeout = cfg.HealthCheckTimeout
+	}
+	if interval == currentInterval && timeout == currentTimeout {
+		return
+	}
+
+	// The probe was started with the previous settings
+	s.stopHealthProbe()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Close existing connection
-	if s.client != nil {
-		if err := s.client.Close(); err != nil {
-			log.Printf("Error closing auth service connection during reconnect: %v", err)
-		}
+	s.healthInterval, s.healthTimeout = interval, timeout
+	if !s.closed {
+		s.startProbe(interval, timeout)
 	}
+	slog.Info("Auth service health check settings changed",
+		"interval", interval, "timeout", timeout)
+}
 
-	// Create new connection
-	client, err := client.NewAuthServiceClient(*cfg)
-	if err != nil {
-		return err
+// clientChanged reports whether settings the client was created with differ
+func clientChanged(previous, current config.AuthServiceConfig) bool {
+	return connectionChanged(previous, current) ||
+		previous.Timeout != current.Timeout ||
+		!maps.Equal(previous.MethodTimeouts, current.MethodTimeouts) ||
+		previous.Retry != current.Retry ||
+		previous.CircuitBreaker != current.CircuitBreaker
+}
+
+// connectionChanged reports whether the settings that shape the gRPC
+// connection itself differ
+func connectionChanged(previous, current config.AuthServiceConfig) bool {
+	return previous.Address != current.Address ||
+		!slices.Equal(previous.Addresses, current.Addresses) ||
+		previous.LoadBalancing != current.LoadBalancing ||
+		previous.TLS != current.TLS
+}
+
+// describeTarget renders the configured replicas for log messages
+func describeTarget(cfg config.AuthServiceConfig) string {
+	if len(cfg.Addresses) > 0 {
+		return strings.Join(cfg.Addresses, ",")
 	}
+	return cfg.Address
+}
 
-	s.client = client
-	return nil
+// Stop waits for in-flight calls until ctx is done, then closes the client
+func (s *AuthService) Stop(ctx context.Context) error {
+	if client := s.GetClient(); client != nil {
+		if err := client.Drain(ctx); err != nil {
+			slog.Warn("Closing auth service connection before drain completed", "error", err)
+		}
+	}
+	return s.Close()
 }
 
-// Close closes the auth service connections
+// Close stops health probing and closes the auth service connections
 func (s *AuthService) Close() error {
+	s.stopHealthProbe()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.closed = true
 	if s.client != nil {
 		return s.client.Close()
 	}
 	return nil
 }
 
-// IsHealthy checks if the auth service client is available
+// IsHealthy reports whether the auth service is reachable, based on the
+// connection state and the most recent health probe
 func (s *AuthService) IsHealthy() bool {
+	return s.Health().Healthy
+}
+
+// Health returns the latest health status of the auth service. The
+// connectivity state is read live so connection failures are visible
+// before the next probe runs.
+func (s *AuthService) Health() HealthStatus {
+	s.healthMu.RLock()
+	health := s.health
+	s.healthMu.RUnlock()
+
+	client := s.GetClient()
+	if client == nil {
+		health.Healthy = false
+		health.State = connectivity.Shutdown.String()
+		return health
+	}
+
+	state := client.State()
+	health.State = state.String()
+	health.CircuitBreaker = client.CircuitBreakerState()
+	if state == connectivity.TransientFailure || state == connectivity.Shutdown {
+		health.Healthy = false
+	}
+	return health
+}
+
+// healthSettings returns the health check interval and timeout
+func (s *AuthService) healthSettings() (interval, timeout time.Duration) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.client != nil
+	return s.healthInterval, s.healthTimeout
+}
+
+// startProbe runs checkHealth every interval until Close is called. The
+// caller holds s.mu.
+func (s *AuthService) startProbe(interval, timeout time.Duration) {
+	s.stopProbe = make(chan struct{})
+	s.probeDone = make(chan struct{})
+
+	go func(stop <-chan struct{}, done chan<- struct{}) {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.recordHealth(s.checkHealth(s.GetClient(), timeout))
+			}
+		}
+	}(s.stopProbe, s.probeDone)
+}
+
+// stopHealthProbe stops the background probe and waits for it to exit
+func (s *AuthService) stopHealthProbe() {
+	s.mu.Lock()
+	stop, done := s.stopProbe, s.probeDone
+	s.stopProbe, s.probeDone = nil, nil
+	s.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// checkHealth performs a single grpc.health.v1 probe, and one per replica
+// when the client balances across several of them, each bounded by timeout
+func (s *AuthService) checkHealth(client *client.AuthServiceClient, timeout time.Duration) HealthStatus {
+	health := HealthStatus{CheckedAt: time.Now()}
+	if client == nil {
+		health.State = connectivity.Shutdown.String()
+		return health.withError(errors.New("auth service client is not initialized"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	servingStatus, err := client.CheckHealth(ctx)
+	health.LatencyMs = durationMs(time.Since(start))
+	health.State = client.State().String()
+
+	switch {
+	case status.Code(err) == codes.Unimplemented:
+		// The server answered, it just does not expose the health service
+		health.Healthy = true
+	case err != nil:
+		health = health.withError(err)
+	case servingStatus != healthpb.HealthCheckResponse_SERVING:
+		health = health.withError(fmt.Errorf("serving status is %s", servingStatus))
+	default:
+		health.Healthy = true
+	}
+
+	backendCtx, backendCancel := context.WithTimeout(context.Background(), timeout)
+	defer backendCancel()
+
+	backends, err := client.CheckBackends(backendCtx)
+	if err != nil {
+		return health.withError(err)
+	}
+	if len(backends) == 0 {
+		return health
+	}
+
+	// The balancer routes around failed replicas, so one healthy replica
+	// is enough to serve requests
+	anyHealthy := false
+	for _, backend := range backends {
+		backendStatus := BackendStatus{
+			Address:   backend.Address,
+			Healthy:   backend.Healthy,
+			LatencyMs: durationMs(backend.Latency),
+		}
+		if backend.Err != nil {
+			backendStatus.Error = backend.Err.Error()
+		}
+		anyHealthy = anyHealthy || backend.Healthy
+		health.Backends = append(health.Backends, backendStatus)
+	}
+	health.Healthy = anyHealthy
+	return health
+}
+
+// recordHealth stores a probe result, keeping the last error across successes
+func (s *AuthService) recordHealth(health HealthStatus) {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+
+	if health.Healthy != s.health.Healthy && !s.health.CheckedAt.IsZero() {
+		level := slog.LevelInfo
+		if !health.Healthy {
+			level = slog.LevelWarn
+		}
+		slog.Log(context.Background(), level, "Auth service health changed",
+			"healthy", health.Healthy,
+			"state", health.State,
+			"error", health.LastError)
+	}
+	if health.LastError == "" {
+		health.LastError = s.health.LastError
+		health.LastErrorAt = s.health.LastErrorAt
+	}
+	s.health = health
 }
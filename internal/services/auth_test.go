@@ -0,0 +1,74 @@
+package services
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	config "github.com/oj-lab/reborn/configs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// startHealthServer serves the gRPC health service on a loopback port
+func startHealthServer(t *testing.T) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func TestAuthServiceAppliesHealthCheckSettings(t *testing.T) {
+	cfg := config.Config{AuthService: config.AuthServiceConfig{
+		Address:             startHealthServer(t),
+		LoadBalancing:       config.LoadBalancingPickFirst,
+		Timeout:             time.Second,
+		HealthCheckInterval: time.Millisecond,
+		HealthCheckTimeout:  time.Second,
+	}}
+
+	s := NewAuthService()
+	if err := s.Start(t.Context(), cfg); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if !s.IsHealthy() {
+		t.Fatalf("unhealthy after start: %+v", s.Health())
+	}
+
+	// Reloads race with the probe and with each other; run with -race
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reloaded := cfg
+			reloaded.AuthService.HealthCheckInterval = time.Duration(i+2) * time.Millisecond
+			reloaded.AuthService.HealthCheckTimeout = time.Duration(i+2) * time.Second
+			if i%2 == 1 {
+				// Also reconnect, which probes the new client right away
+				reloaded.AuthService.Timeout = time.Duration(i+2) * time.Second
+			}
+			if err := s.ApplyConfig(t.Context(), reloaded); err != nil {
+				t.Errorf("ApplyConfig: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if interval, timeout := s.healthSettings(); interval < 2*time.Millisecond || timeout < 2*time.Second {
+		t.Errorf("health settings %v, %v, want one of the reloaded ones", interval, timeout)
+	}
+	if !s.IsHealthy() {
+		t.Errorf("unhealthy after reloading: %+v", s.Health())
+	}
+}
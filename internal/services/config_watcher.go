@@ -0,0 +1,126 @@
+package services
+
+import (
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	config "github.com/oj-lab/reborn/configs"
+)
+
+// configReloadDelay coalesces the burst of events editors emit on save
+const configReloadDelay = 200 * time.Millisecond
+
+// configWatcher reloads configuration when files in the config directory change
+type configWatcher struct {
+	watcher *fsnotify.Watcher
+	onLoad  func(config.Config)
+	done    chan struct{}
+
+	mu     sync.Mutex
+	timer  *time.Timer
+	closed bool
+	// reloading is held while a reload runs, so that Close can wait for it
+	reloading sync.Mutex
+}
+
+func newConfigWatcher(dir string, onLoad func(config.Config)) (*configWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// Watch the directory rather than the files to survive atomic renames
+	if err := watcher.Add(dir); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+
+	w := &configWatcher{
+		watcher: watcher,
+		onLoad:  onLoad,
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+func (w *configWatcher) run() {
+	defer close(w.done)
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Ext(event.Name) != ".toml" ||
+				!event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) &&
+					!event.Has(fsnotify.Rename) {
+				continue
+			}
+			w.scheduleReload()
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Error("Config watcher error", "error", err)
+		}
+	}
+}
+
+func (w *configWatcher) scheduleReload() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return
+	}
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(configReloadDelay, w.reload)
+}
+
+func (w *configWatcher) reload() {
+	w.reloading.Lock()
+	defer w.reloading.Unlock()
+
+	w.mu.Lock()
+	closed := w.closed
+	w.mu.Unlock()
+	if closed {
+		return
+	}
+
+	if err := config.Reload(); err != nil {
+		slog.Error("Failed to reload config, keeping current settings", "error", err)
+		return
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("Invalid config, keeping current settings", "error", err)
+		return
+	}
+	slog.Info("Config reloaded")
+	w.onLoad(cfg)
+}
+
+// Close stops watching and waits for the event loop and a running reload to
+// exit, so that no config is applied afterwards
+func (w *configWatcher) Close() error {
+	w.mu.Lock()
+	w.closed = true
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.mu.Unlock()
+
+	err := w.watcher.Close()
+	<-w.done
+
+	w.reloading.Lock()
+	defer w.reloading.Unlock()
+	return err
+}
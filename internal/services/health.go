@@ -0,0 +1,41 @@
+package services
+
+import "time"
+
+// HealthStatus describes the observed health of a downstream service
+type HealthStatus struct {
+	Healthy        bool      `json:"healthy"`
+	State          string    `json:"state"`
+	CircuitBreaker string    `json:"circuit_breaker,omitempty"`
+	LatencyMs      float64   `json:"latency_ms"`
+	LastError      string    `json:"last_error,omitempty"`
+	LastErrorAt    time.Time `json:"last_error_at,omitzero"`
+	CheckedAt      time.Time `json:"checked_at,omitzero"`
+	// Version of the downstream server, when it reports one. Only the Redis
+	// rate limit store does; the auth service exposes no version through its
+	// API, health or reflection services, so its entry never has one.
+	Version string `json:"version,omitempty"`
+	// Backends is reported for services balanced across several replicas
+	Backends []BackendStatus `json:"backends,omitempty"`
+}
+
+// BackendStatus describes the health of a single replica of a service
+type BackendStatus struct {
+	Address   string  `json:"address"`
+	Healthy   bool    `json:"healthy"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// withError marks the status unhealthy and records err
+func (h HealthStatus) withError(err error) HealthStatus {
+	h.Healthy = false
+	h.LastError = err.Error()
+	h.LastErrorAt = h.CheckedAt
+	return h
+}
+
+// durationMs converts d to fractional milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/oj-lab/go-webmods/redis_client"
+	config "github.com/oj-lab/reborn/configs"
+	"github.com/oj-lab/reborn/internal/ratelimit"
+)
+
+// RateLimitServiceName identifies the rate limit service in the ServiceManager
+const RateLimitServiceName = "rate_limit"
+
+// RateLimitService counts requests against the configured rules in a
+// memory or Redis store
+type RateLimitService struct {
+	store     ratelimit.Store
+	storeName string
+	rules     map[string]config.RateLimitRule
+	mu        sync.RWMutex
+
+	health   HealthStatus
+	healthMu sync.RWMutex
+}
+
+// NewRateLimitService creates a new RateLimitService instance
+func NewRateLimitService() *RateLimitService {
+	return &RateLimitService{}
+}
+
+// Name implements Service
+func (s *RateLimitService) Name() string {
+	return RateLimitServiceName
+}
+
+// Dependencies implements Service
+func (s *RateLimitService) Dependencies() []string {
+	return nil
+}
+
+// Optional implements OptionalService, requests are let through while the
+// store is unavailable
+func (s *RateLimitService) Optional() bool {
+	return true
+}
+
+// Start opens the configured store. An unreachable Redis server does not
+// fail startup, the service falls back to a memory store until restarted.
+func (s *RateLimitService) Start(ctx context.Context, appCfg config.Config) error {
+	cfg := appCfg.RateLimit
+
+	var (
+		store     ratelimit.Store
+		storeName = cfg.Store
+		version   string
+		redisErr  error
+	)
+	switch cfg.Store {
+	case config.RateLimitStoreMemory, "":
+		store = ratelimit.NewMemoryStore()
+		storeName = config.RateLimitStoreMemory
+	case config.RateLimitStoreRedis:
+		if len(cfg.Redis.URLs) == 0 {
+			return errors.New("rate limit store is redis but no redis urls are configured")
+		}
+		redisStore := ratelimit.NewRedisStore(
+			redis_client.NewRDB(redis_client.Config{
+				Urls:     cfg.Redis.URLs,
+				Password: cfg.Redis.Password,
+			}),
+			cfg.Redis.KeyPrefix,
+		)
+		serverVersion, err := redisStore.ServerVersion(ctx)
+		if err != nil {
+			_ = redisStore.Close()
+			redisErr = fmt.Errorf("failed to connect to rate limit redis: %w", err)
+			slog.Warn("Rate limit redis unavailable, falling back to memory store until restart",
+				"error", err)
+			store = ratelimit.NewMemoryStore()
+			storeName = config.RateLimitStoreMemory
+			break
+		}
+		version = serverVersion
+		store = redisStore
+	default:
+		return fmt.Errorf("unknown rate limit store %q", cfg.Store)
+	}
+
+	s.mu.Lock()
+	s.store = store
+	s.storeName = storeName
+	s.rules = cfg.Rules
+	s.mu.Unlock()
+
+	health := HealthStatus{
+		Healthy:   true,
+		State:     storeName,
+		CheckedAt: time.Now(),
+		Version:   version,
+	}
+	if redisErr != nil {
+		// Limits still apply, just per replica
+		health.LastError = redisErr.Error()
+		health.LastErrorAt = health.CheckedAt
+	}
+	s.healthMu.Lock()
+	s.health = health
+	s.healthMu.Unlock()
+
+	slog.Info("Rate limit store ready", "store", storeName, "rules", len(cfg.Rules))
+	return nil
+}
+
+// Stop closes the store
+func (s *RateLimitService) Stop(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.store == nil {
+		return nil
+	}
+	err := s.store.Close()
+	s.store = nil
+	return err
+}
+
+// Health implements Service
+func (s *RateLimitService) Health() HealthStatus {
+	s.healthMu.RLock()
+	defer s.healthMu.RUnlock()
+	return s.health
+}
+
+// ApplyConfig implements ConfigReloader. Rules take effect immediately, a
+// different store is only picked up on restart.
+func (s *RateLimitService) ApplyConfig(_ context.Context, appCfg config.Config) error {
+	cfg := appCfg.RateLimit
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rules = cfg.Rules
+	if cfg.Store != "" && cfg.Store != s.storeName {
+		slog.Warn("Rate limit store changes require a restart",
+			"current", s.storeName,
+			"configured", cfg.Store,
+		)
+	}
+	return nil
+}
+
+// Allow records a hit by identity against the named rule. It reports false
+// when the rule is missing or disabled, in which case nothing is limited.
+func (s *RateLimitService) Allow(
+	ctx context.Context,
+	ruleName, identity string,
+) (ratelimit.Result, bool, error) {
+	s.mu.RLock()
+	store := s.store
+	rule, ok := s.rules[ruleName]
+	s.mu.RUnlock()
+
+	if !ok || rule.Requests <= 0 || rule.Window <= 0 || store == nil {
+		return ratelimit.Result{}, false, nil
+	}
+
+	result, err := ratelimit.Allow(ctx, store, ruleName+":"+identity, rule)
+	s.recordHealth(err)
+	if err != nil {
+		return ratelimit.Result{}, false, err
+	}
+	return result, true, nil
+}
+
+// recordHealth tracks whether the store answered the last request
+func (s *RateLimitService) recordHealth(err error) {
+	// Skip the write lock on the hot path while the store keeps working
+	s.healthMu.RLock()
+	unchanged := err == nil && s.health.Healthy
+	s.healthMu.RUnlock()
+	if unchanged {
+		return
+	}
+
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+
+	wasHealthy := s.health.Healthy
+	s.health.CheckedAt = time.Now()
+	if err != nil {
+		s.health = s.health.withError(err)
+		if wasHealthy {
+			slog.Warn("Rate limit store failed, allowing requests", "error", err)
+		}
+		return
+	}
+	s.health.Healthy = true
+	if !wasHealthy {
+		slog.Info("Rate limit store recovered")
+	}
+}
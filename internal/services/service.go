@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	config "github.com/oj-lab/reborn/configs"
+)
+
+// Service is a component whose lifecycle is managed by ServiceManager
+type Service interface {
+	// Name uniquely identifies the service, e.g. in health reports
+	Name() string
+	// Dependencies lists the names of services that must start first
+	Dependencies() []string
+	// Start brings the service up with the given configuration
+	Start(ctx context.Context, cfg config.Config) error
+	// Stop releases all resources held by the service
+	Stop(ctx context.Context) error
+	// Health reports the current health of the service
+	Health() HealthStatus
+}
+
+// ConfigReloader is implemented by services that can apply a changed
+// configuration without being restarted
+type ConfigReloader interface {
+	ApplyConfig(ctx context.Context, cfg config.Config) error
+}
+
+// OptionalService is implemented by services that degrade gracefully, so
+// that their failure does not make the application unready
+type OptionalService interface {
+	Optional() bool
+}
+
+// isRequired reports whether svc must be healthy for the application to
+// serve traffic
+func isRequired(svc Service) bool {
+	optional, ok := svc.(OptionalService)
+	return !ok || !optional.Optional()
+}
+
+// startOrder sorts services so that every service comes after its
+// dependencies, keeping registration order among independent services
+func startOrder(services []Service) ([]Service, error) {
+	byName := make(map[string]Service, len(services))
+	for _, svc := range services {
+		byName[svc.Name()] = svc
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := make(map[string]int, len(services))
+	ordered := make([]Service, 0, len(services))
+
+	var visit func(svc Service, path []string) error
+	visit = func(svc Service, path []string) error {
+		name := svc.Name()
+		switch marks[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle: %v", append(path, name))
+		}
+
+		marks[name] = visiting
+		for _, depName := range svc.Dependencies() {
+			dep, ok := byName[depName]
+			if !ok {
+				return fmt.Errorf("service %s depends on unknown service %s", name, depName)
+			}
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		marks[name] = visited
+		ordered = append(ordered, svc)
+		return nil
+	}
+
+	for _, svc := range services {
+		if err := visit(svc, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
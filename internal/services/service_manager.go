@@ -1,77 +1,261 @@
 package services
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
 	"sync"
+	"sync/atomic"
 
 	config "github.com/oj-lab/reborn/configs"
 )
 
 // ServiceManager manages all application services
 type ServiceManager struct {
-	authService *AuthService
-	mu          sync.RWMutex
+	services []Service
+	started  []Service
+	cfg      config.Config
+	watcher  *configWatcher
+	mu       sync.RWMutex
+	// draining is set once shutdown begins, to fail readiness checks
+	draining atomic.Bool
 }
 
-// NewServiceManager creates a new service manager instance
+// NewServiceManager creates a new service manager instance with the core
+// services registered
 func NewServiceManager() *ServiceManager {
-	return &ServiceManager{}
+	sm := &ServiceManager{}
+	for _, svc := range []Service{NewAuthService(), NewRateLimitService()} {
+		if err := sm.Register(svc); err != nil {
+			panic(err)
+		}
+	}
+	return sm
 }
 
-// Initialize sets up all services with configuration
+// Register adds a service to be started by Initialize
+func (sm *ServiceManager) Register(svc Service) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if sm.started != nil {
+		return fmt.Errorf("cannot register service %s after initialization", svc.Name())
+	}
+	for _, existing := range sm.services {
+		if existing.Name() == svc.Name() {
+			return fmt.Errorf("service %s is already registered", svc.Name())
+		}
+	}
+
+	sm.services = append(sm.services, svc)
+	return nil
+}
+
+// Initialize starts all registered services in dependency order. If any
+// service fails to start, the ones already started are stopped again.
 func (sm *ServiceManager) Initialize(cfg config.Config) error {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
-	// Initialize auth service
-	sm.authService = NewAuthService()
-	if err := sm.authService.Initialize(cfg.AuthService); err != nil {
+	ordered, err := startOrder(sm.services)
+	if err != nil {
 		return err
 	}
 
-	log.Println("Service manager initialized successfully")
+	sm.cfg = cfg
+	ctx := context.Background()
+	started := make([]Service, 0, len(ordered))
+	for _, svc := range ordered {
+		if err := svc.Start(ctx, cfg); err != nil {
+			startErr := fmt.Errorf("failed to start %s: %w", svc.Name(), err)
+			return errors.Join(startErr, stopAll(ctx, started))
+		}
+		slog.Info("Service started", "service", svc.Name())
+		started = append(started, svc)
+	}
+	sm.started = started
+
+	slog.Info("Service manager initialized successfully")
 	return nil
 }
 
-// GetAuthService returns the auth service instance
-func (sm *ServiceManager) GetAuthService() *AuthService {
+// WatchConfig reloads configuration whenever a file in dir changes and
+// passes it to every service implementing ConfigReloader
+func (sm *ServiceManager) WatchConfig(dir string) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if sm.watcher != nil {
+		return nil
+	}
+
+	watcher, err := newConfigWatcher(dir, sm.applyConfig)
+	if err != nil {
+		return err
+	}
+	sm.watcher = watcher
+	slog.Info("Watching config directory for changes", "dir", dir)
+	return nil
+}
+
+// applyConfig hands a reloaded configuration to the running services
+func (sm *ServiceManager) applyConfig(cfg config.Config) {
+	sm.mu.Lock()
+	if sm.started == nil {
+		// Shutdown already stopped the services
+		sm.mu.Unlock()
+		return
+	}
+	sm.cfg = cfg
+	started := slices.Clone(sm.started)
+	sm.mu.Unlock()
+
+	ctx := context.Background()
+	for _, svc := range started {
+		reloader, ok := svc.(ConfigReloader)
+		if !ok {
+			continue
+		}
+		if err := reloader.ApplyConfig(ctx, cfg); err != nil {
+			slog.Error("Failed to apply config", "service", svc.Name(), "error", err)
+		}
+	}
+}
+
+// Config returns the configuration last handed to the services
+func (sm *ServiceManager) Config() config.Config {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
-	return sm.authService
+	return sm.cfg
 }
 
-// Shutdown gracefully shuts down all services
-func (sm *ServiceManager) Shutdown() error {
+// Get returns a registered service by name
+func (sm *ServiceManager) Get(name string) (Service, bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	for _, svc := range sm.services {
+		if svc.Name() == name {
+			return svc, true
+		}
+	}
+	return nil, false
+}
+
+// GetAuthService returns the auth service instance
+func (sm *ServiceManager) GetAuthService() *AuthService {
+	svc, ok := sm.Get(AuthServiceName)
+	if !ok {
+		return nil
+	}
+	authService, _ := svc.(*AuthService)
+	return authService
+}
+
+// GetRateLimitService returns the rate limit service instance
+func (sm *ServiceManager) GetRateLimitService() *RateLimitService {
+	svc, ok := sm.Get(RateLimitServiceName)
+	if !ok {
+		return nil
+	}
+	rateLimitService, _ := svc.(*RateLimitService)
+	return rateLimitService
+}
+
+// BeginShutdown marks the application as unready so that load balancers stop
+// sending new traffic before services are stopped
+func (sm *ServiceManager) BeginShutdown() {
+	if !sm.draining.Swap(true) {
+		slog.Info("Readiness disabled for shutdown")
+	}
+}
+
+// Draining reports whether shutdown has begun
+func (sm *ServiceManager) Draining() bool {
+	return sm.draining.Load()
+}
+
+// Shutdown stops all started services in reverse start order and returns
+// every error encountered
+func (sm *ServiceManager) Shutdown(ctx context.Context) error {
+	sm.BeginShutdown()
+
 	sm.mu.Lock()
-	defer sm.mu.Unlock()
+	watcher := sm.watcher
+	sm.watcher = nil
+	sm.mu.Unlock()
 
-	var lastErr error
+	var errs []error
 
-	// Close auth service
-	if sm.authService != nil {
-		if err := sm.authService.Close(); err != nil {
-			log.Printf("Error closing auth service: %v", err)
-			lastErr = err
+	// Stop reacting to config changes before stopping services. Close waits
+	// for a running reload, which needs the lock to apply the config.
+	if watcher != nil {
+		if err := watcher.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close config watcher: %w", err))
 		}
 	}
 
-	log.Println("Service manager shutdown completed")
-	return lastErr
+	// Stopping may wait for in-flight calls to drain, so it must not block
+	// health checks and readiness probes meanwhile
+	sm.mu.Lock()
+	started := sm.started
+	sm.started = nil
+	sm.mu.Unlock()
+
+	errs = append(errs, stopAll(ctx, started))
+
+	slog.Info("Service manager shutdown completed")
+	return errors.Join(errs...)
 }
 
-// HealthCheck returns the health status of all services
-func (sm *ServiceManager) HealthCheck() map[string]bool {
+// HealthCheck returns the health status of every registered service
+func (sm *ServiceManager) HealthCheck() map[string]HealthStatus {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
 
-	health := make(map[string]bool)
+	health := make(map[string]HealthStatus, len(sm.services))
+	for _, svc := range sm.services {
+		if !slices.Contains(sm.started, svc) {
+			health[svc.Name()] = HealthStatus{LastError: "service is not started"}
+			continue
+		}
+		health[svc.Name()] = svc.Health()
+	}
+	return health
+}
+
+// Ready reports whether every required service is started and healthy, and
+// shutdown has not begun. The returned map lists the health of each service.
+func (sm *ServiceManager) Ready() (bool, map[string]bool) {
+	health := sm.HealthCheck()
 
-	// Check auth service health
-	if sm.authService != nil {
-		health["auth_service"] = sm.authService.IsHealthy()
-	} else {
-		health["auth_service"] = false
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	ready := !sm.draining.Load()
+	services := make(map[string]bool, len(sm.services))
+	for _, svc := range sm.services {
+		healthy := health[svc.Name()].Healthy
+		services[svc.Name()] = healthy
+		if !healthy && isRequired(svc) {
+			ready = false
+		}
 	}
+	return ready, services
+}
 
-	return health
+// stopAll stops services in reverse order, collecting all errors
+func stopAll(ctx context.Context, started []Service) error {
+	var errs []error
+	for _, svc := range slices.Backward(started) {
+		if err := svc.Stop(ctx); err != nil {
+			slog.Error("Error stopping service", "service", svc.Name(), "error", err)
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", svc.Name(), err))
+			continue
+		}
+		slog.Info("Service stopped", "service", svc.Name())
+	}
+	return errors.Join(errs...)
 }
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	config "github.com/oj-lab/reborn/configs"
+)
+
+// fakeService records its lifecycle calls in a shared log
+type fakeService struct {
+	name     string
+	deps     []string
+	optional bool
+	healthy  bool
+	startErr error
+	// stopped, when set, is closed once Stop is called, and Stop blocks
+	// until release is closed
+	stopped, release chan struct{}
+	log              *[]string
+}
+
+func (s *fakeService) Name() string           { return s.name }
+func (s *fakeService) Dependencies() []string { return s.deps }
+func (s *fakeService) Optional() bool         { return s.optional }
+
+func (s *fakeService) Start(context.Context, config.Config) error {
+	if s.startErr != nil {
+		return s.startErr
+	}
+	*s.log = append(*s.log, "start "+s.name)
+	return nil
+}
+
+func (s *fakeService) Stop(context.Context) error {
+	*s.log = append(*s.log, "stop "+s.name)
+	if s.stopped != nil {
+		close(s.stopped)
+		<-s.release
+	}
+	return nil
+}
+
+func (s *fakeService) Health() HealthStatus {
+	return HealthStatus{Healthy: s.healthy}
+}
+
+func names(services []Service) []string {
+	result := make([]string, len(services))
+	for i, svc := range services {
+		result[i] = svc.Name()
+	}
+	return result
+}
+
+func TestStartOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		services []Service
+		want     []string
+		wantErr  string
+	}{
+		{
+			name:     "registration order without dependencies",
+			services: []Service{&fakeService{name: "a"}, &fakeService{name: "b"}},
+			want:     []string{"a", "b"},
+		},
+		{
+			name: "dependencies first",
+			services: []Service{
+				&fakeService{name: "api", deps: []string{"auth", "cache"}},
+				&fakeService{name: "auth", deps: []string{"cache"}},
+				&fakeService{name: "cache"},
+			},
+			want: []string{"cache", "auth", "api"},
+		},
+		{
+			name:     "unknown dependency",
+			services: []Service{&fakeService{name: "api", deps: []string{"db"}}},
+			wantErr:  "api depends on unknown service db",
+		},
+		{
+			name: "cycle",
+			services: []Service{
+				&fakeService{name: "a", deps: []string{"b"}},
+				&fakeService{name: "b", deps: []string{"a"}},
+			},
+			wantErr: "dependency cycle: [a b a]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ordered, err := startOrder(tt.services)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("startOrder() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("startOrder() failed: %v", err)
+			}
+			if got := names(ordered); !slices.Equal(got, tt.want) {
+				t.Errorf("startOrder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitializeRollsBackOnFailure(t *testing.T) {
+	var log []string
+	sm := &ServiceManager{}
+	for _, svc := range []Service{
+		&fakeService{name: "a", log: &log},
+		&fakeService{name: "b", deps: []string{"a"}, log: &log},
+		&fakeService{name: "c", deps: []string{"b"}, startErr: errors.New("boom"), log: &log},
+	} {
+		if err := sm.Register(svc); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	}
+
+	err := sm.Initialize(config.Config{})
+	if err == nil || !strings.Contains(err.Error(), "failed to start c: boom") {
+		t.Fatalf("Initialize() error = %v, want c to fail", err)
+	}
+	if want := []string{"start a", "start b", "stop b", "stop a"}; !slices.Equal(log, want) {
+		t.Errorf("lifecycle %v, want %v", log, want)
+	}
+	if ready, _ := sm.Ready(); ready {
+		t.Error("ready after a failed initialization")
+	}
+}
+
+func TestReady(t *testing.T) {
+	var log []string
+	required := &fakeService{name: "required", healthy: true, log: &log}
+	optional := &fakeService{name: "optional", optional: true, log: &log}
+	sm := &ServiceManager{}
+	for _, svc := range []Service{required, optional} {
+		if err := sm.Register(svc); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	}
+
+	if ready, _ := sm.Ready(); ready {
+		t.Error("ready before initialization")
+	}
+	if err := sm.Initialize(config.Config{}); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	ready, services := sm.Ready()
+	if !ready {
+		t.Error("not ready while only an optional service is unhealthy")
+	}
+	if want := map[string]bool{"required": true, "optional": false}; !maps.Equal(services, want) {
+		t.Errorf("services = %v, want %v", services, want)
+	}
+
+	required.healthy = false
+	if ready, _ := sm.Ready(); ready {
+		t.Error("ready while a required service is unhealthy")
+	}
+
+	required.healthy = true
+	sm.BeginShutdown()
+	if ready, _ := sm.Ready(); ready {
+		t.Error("ready after shutdown began")
+	}
+}
+
+func TestShutdownDoesNotBlockHealthChecks(t *testing.T) {
+	var log []string
+	svc := &fakeService{
+		name: "slow", healthy: true, log: &log,
+		stopped: make(chan struct{}), release: make(chan struct{}),
+	}
+	sm := &ServiceManager{}
+	if err := sm.Register(svc); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := sm.Initialize(config.Config{}); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- sm.Shutdown(context.Background()) }()
+	<-svc.stopped
+
+	checked := make(chan struct{})
+	go func() {
+		sm.Ready()
+		close(checked)
+	}()
+	select {
+	case <-checked:
+	case <-time.After(time.Second):
+		t.Fatal("Ready blocked while a service was stopping")
+	}
+
+	close(svc.release)
+	if err := <-done; err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package static
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DevProxy forwards website requests to a frontend dev server such as Vite,
+// so that the backend and hot reloading share one origin. HTML documents
+// are rendered like the built shell, with the page head, the runtime
+// configuration and the CSP nonce.
+type DevProxy struct {
+	target *url.URL
+	shell  *Shell
+	proxy  *httputil.ReverseProxy
+}
+
+// devProxyRequest carries the per-request values of a proxied request
+type devProxyRequest struct {
+	head  Head
+	nonce string
+	err   error
+}
+
+type devProxyRequestKey struct{}
+
+// NewDevProxy creates a proxy to the dev server at rawURL, rendering its
+// documents with shell
+func NewDevProxy(rawURL string, shell *Shell) (*DevProxy, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dev proxy URL: %w", err)
+	}
+	if (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("dev proxy URL %q must be an absolute http(s) URL", rawURL)
+	}
+
+	p := &DevProxy{target: target, shell: shell}
+	p.proxy = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			// Documents are rewritten, and responses are compressed by the
+			// backend anyway
+			pr.Out.Header.Del("Accept-Encoding")
+		},
+		ModifyResponse: p.modifyResponse,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			req, ok := proxiedRequest(r.Context())
+			if !ok {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			req.err = err
+		},
+		// Stream server-sent events and module responses as they come
+		FlushInterval: -1,
+	}
+	return p, nil
+}
+
+// Target returns the URL of the dev server
+func (p *DevProxy) Target() *url.URL {
+	return p.target
+}
+
+// Serve proxies a request, including websocket upgrades for hot module
+// replacement. It returns the error of an unreachable dev server without
+// writing a response.
+func (p *DevProxy) Serve(w http.ResponseWriter, r *http.Request, head Head, nonce string) error {
+	if r.Header.Get("Upgrade") != "" {
+		// Upgraded connections outlive the server timeouts of a request
+		rc := http.NewResponseController(w)
+		_ = rc.SetReadDeadline(time.Time{})
+		_ = rc.SetWriteDeadline(time.Time{})
+	}
+
+	req := &devProxyRequest{head: head, nonce: nonce}
+	p.proxy.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), devProxyRequestKey{}, req)))
+	if req.err != nil {
+		return fmt.Errorf("dev server %s: %w", p.target.Host, req.err)
+	}
+	return nil
+}
+
+// modifyResponse renders HTML documents of the dev server
+func (p *DevProxy) modifyResponse(resp *http.Response) error {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if resp.StatusCode != http.StatusOK || mediaType != "text/html" {
+		return nil
+	}
+	req, ok := proxiedRequest(resp.Request.Context())
+	if !ok {
+		// Only Serve knows the head and nonce to render with
+		return nil
+	}
+
+	html, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	html = p.shell.RenderHTML(html, req.head, req.nonce)
+
+	resp.Body = io.NopCloser(bytes.NewReader(html))
+	resp.ContentLength = int64(len(html))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(html)))
+	resp.Header.Set("Cache-Control", "no-cache")
+	// The document differs on every request
+	resp.Header.Del("ETag")
+	resp.Header.Del("Last-Modified")
+	return nil
+}
+
+// proxiedRequest returns the values Serve attached to a request context
+func proxiedRequest(ctx context.Context) (*devProxyRequest, bool) {
+	req, ok := ctx.Value(devProxyRequestKey{}).(*devProxyRequest)
+	return req, ok && req != nil
+}
@@ -0,0 +1,202 @@
+package static
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const viteIndex = `<!doctype html><html><head><title>Vite</title>` +
+	`<script type="module" src="/@vite/client"></script></head><body></body></html>`
+
+// startDevServer starts a stand-in for a frontend dev server serving an
+// HTML document, a module and an echoing websocket endpoint
+func startDevServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("ETag", `"vite"`)
+		_, _ = io.WriteString(w, viteIndex)
+	})
+	mux.HandleFunc("/src/main.ts", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/javascript")
+		_, _ = io.WriteString(w, "console.log('<title>')")
+	})
+	mux.HandleFunc("/hmr", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			http.Error(w, "upgrade required", http.StatusUpgradeRequired)
+			return
+		}
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+			"Connection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+		_ = rw.Flush()
+		line, _ := rw.ReadString('\n')
+		_, _ = rw.WriteString("echo " + line)
+		_ = rw.Flush()
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// startDevProxy serves p in front of the dev server, recording the errors
+// returned by Serve
+func startDevProxy(t *testing.T, p *DevProxy, errs chan<- error) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := p.Serve(w, r, Head{Title: "Problems"}, "n0nce"); err != nil {
+			errs <- err
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestDevProxy(t *testing.T, target string) *DevProxy {
+	t.Helper()
+
+	p, err := NewDevProxy(target, NewShell(fstest.MapFS{}, []byte(`{"dev":true}`)))
+	if err != nil {
+		t.Fatalf("NewDevProxy: %v", err)
+	}
+	return p
+}
+
+func TestDevProxyRendersDocuments(t *testing.T) {
+	proxy := startDevProxy(t, newTestDevProxy(t, startDevServer(t).URL), make(chan error, 1))
+
+	resp, err := http.Get(proxy.URL + "/problems")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	html := string(body)
+
+	for _, want := range []string{
+		"<title>Problems</title>",
+		RuntimeConfigVar,
+		`<script nonce="n0nce" type="module" src="/@vite/client">`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("document %q does not contain %q", html, want)
+		}
+	}
+	if strings.Contains(html, "<title>Vite</title>") {
+		t.Errorf("dev server title kept in %q", html)
+	}
+	if resp.Header.Get("ETag") != "" || resp.Header.Get("Cache-Control") != "no-cache" {
+		t.Errorf("ETag %q, Cache-Control %q, want an uncached document",
+			resp.Header.Get("ETag"), resp.Header.Get("Cache-Control"))
+	}
+	if resp.ContentLength != int64(len(body)) {
+		t.Errorf("Content-Length %d for a %d byte document", resp.ContentLength, len(body))
+	}
+
+	// Other responses pass through untouched
+	resp, err = http.Get(proxy.URL + "/src/main.ts")
+	if err != nil {
+		t.Fatalf("GET module: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "console.log('<title>')" {
+		t.Errorf("module = %q, want it unchanged", body)
+	}
+}
+
+func TestDevProxyUpgradesWebsockets(t *testing.T) {
+	proxy := startDevProxy(t, newTestDevProxy(t, startDevServer(t).URL), make(chan error, 1))
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(proxy.URL, "http://"))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	_, _ = io.WriteString(conn, "GET /hmr HTTP/1.1\r\nHost: localhost\r\n"+
+		"Connection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatalf("read upgrade response: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d, want 101", resp.StatusCode)
+	}
+
+	_, _ = io.WriteString(conn, "ping\n")
+	line, err := r.ReadString('\n')
+	if err != nil || line != "echo ping\n" {
+		t.Errorf("echo = %q, %v", line, err)
+	}
+}
+
+func TestDevProxyReportsUnreachableServer(t *testing.T) {
+	server := startDevServer(t)
+	target := server.URL
+	server.Close()
+
+	errs := make(chan error, 1)
+	proxy := startDevProxy(t, newTestDevProxy(t, target), errs)
+	resp, err := http.Get(proxy.URL + "/")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("status = %d, want 502", resp.StatusCode)
+	}
+	select {
+	case err := <-errs:
+		var opErr *net.OpError
+		if !errors.As(err, &opErr) {
+			t.Errorf("Serve() error = %v, want the dial error", err)
+		}
+	default:
+		t.Error("Serve() returned no error")
+	}
+}
+
+func TestDevProxyWithoutServe(t *testing.T) {
+	p := newTestDevProxy(t, startDevServer(t).URL)
+	proxy := httptest.NewServer(p.proxy)
+	t.Cleanup(proxy.Close)
+
+	resp, err := http.Get(proxy.URL + "/")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != viteIndex {
+		t.Errorf("document = %q, want it unchanged without request values", body)
+	}
+
+	server := startDevServer(t)
+	unreachable := newTestDevProxy(t, server.URL)
+	server.Close()
+	rec := httptest.NewRecorder()
+	unreachable.proxy.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("unreachable dev server status = %d, want 502", rec.Code)
+	}
+}
@@ -0,0 +1,179 @@
+package static
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/oj-lab/reborn/internal/compress"
+)
+
+// contentTypes covers extensions of web builds that are missing from the
+// standard library table and from minimal container images
+var contentTypes = map[string]string{
+	".css":         "text/css; charset=utf-8",
+	".html":        "text/html; charset=utf-8",
+	".ico":         "image/x-icon",
+	".js":          "text/javascript; charset=utf-8",
+	".json":        "application/json",
+	".map":         "application/json",
+	".mjs":         "text/javascript; charset=utf-8",
+	".otf":         "font/otf",
+	".svg":         "image/svg+xml",
+	".ttf":         "font/ttf",
+	".txt":         "text/plain; charset=utf-8",
+	".wasm":        "application/wasm",
+	".webmanifest": "application/manifest+json",
+	".woff":        "font/woff",
+	".woff2":       "font/woff2",
+	".xml":         "text/xml; charset=utf-8",
+}
+
+// ContentType returns the MIME type of a file name, or an empty string when
+// it can only be detected from the content
+func ContentType(name string) string {
+	ext := path.Ext(name)
+	if ct, ok := contentTypes[ext]; ok {
+		return ct
+	}
+	return mime.TypeByExtension(ext)
+}
+
+// Files serves files of a website build with validators, answering
+// conditional and range requests
+type Files struct {
+	fsys fs.FS
+	// etags caches the content hash of the last seen version of each file
+	etags sync.Map
+}
+
+// cachedETag is the validator of a version of a file. Embedded files have
+// no modification time, but never change either.
+type cachedETag struct {
+	size    int64
+	modTime time.Time
+	etag    string
+}
+
+// NewFiles creates a file server for fsys
+func NewFiles(fsys fs.FS) *Files {
+	return &Files{fsys: fsys}
+}
+
+// FS returns the served file system
+func (f *Files) FS() fs.FS {
+	return f.fsys
+}
+
+// Serve writes the named file, or a precompressed sibling such as
+// name.br when the client accepts its encoding. It returns an error
+// wrapping fs.ErrNotExist when the file does not exist, without writing a
+// response.
+func (f *Files) Serve(w http.ResponseWriter, r *http.Request, name string) error {
+	header := w.Header()
+	contentType := ContentType(name)
+
+	fileName := name
+	coding, encodedName, hasEncodings := f.precompressed(r, name)
+	if coding != "" {
+		fileName = encodedName
+	}
+
+	file, err := f.fsys.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory: %w", name, fs.ErrNotExist)
+	}
+
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return err
+		}
+		content = bytes.NewReader(data)
+	}
+
+	etag, err := f.etag(fileName, info, content)
+	if err != nil {
+		return err
+	}
+
+	// The headers describe the file actually served, so they are only set
+	// once it could be read
+	if hasEncodings {
+		header.Add("Vary", "Accept-Encoding")
+	}
+	if coding != "" {
+		header.Set("Content-Encoding", coding)
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+	}
+	header.Set("ETag", etag)
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	// Sniffs the content type when unknown and handles If-None-Match,
+	// If-Modified-Since and Range
+	http.ServeContent(w, r, name, info.ModTime(), content)
+	return nil
+}
+
+// precompressed reports whether name has precompressed siblings, and which
+// of them matches the accepted encodings of r, if any
+func (f *Files) precompressed(r *http.Request, name string) (string, string, bool) {
+	var available []string
+	for _, coding := range compress.Encodings {
+		if info, err := fs.Stat(f.fsys, name+compress.Extensions[coding]); err == nil &&
+			!info.IsDir() {
+			available = append(available, coding)
+		}
+	}
+	if len(available) == 0 {
+		return "", "", false
+	}
+
+	coding := compress.Negotiate(r.Header.Get("Accept-Encoding"), available...)
+	if coding == "" {
+		return "", "", true
+	}
+	return coding, name + compress.Extensions[coding], true
+}
+
+// etag returns the strong validator of a file, hashing it on first use
+func (f *Files) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	if cached, ok := f.etags.Load(name); ok {
+		cached := cached.(cachedETag)
+		if cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+			return cached.etag, nil
+		}
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + base64.RawURLEncoding.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	f.etags.Store(name, cachedETag{size: info.Size(), modTime: info.ModTime(), etag: etag})
+	return etag, nil
+}
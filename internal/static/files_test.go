@@ -0,0 +1,96 @@
+package static
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var precompressedFiles = fstest.MapFS{
+	"app.js":    {Data: []byte("console.log(1)")},
+	"app.js.br": {Data: []byte("brotli")},
+	"app.js.gz": {Data: []byte("gzip")},
+}
+
+// unreadableFS fails to open files with the given suffix, which it still
+// reports through Stat
+type unreadableFS struct {
+	fstest.MapFS
+	suffix string
+}
+
+func (f unreadableFS) Open(name string) (fs.File, error) {
+	if strings.HasSuffix(name, f.suffix) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return f.MapFS.Open(name)
+}
+
+func serveFile(t *testing.T, fsys fs.FS, acceptEncoding string) (*httptest.ResponseRecorder, error) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/app.js", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	rec := httptest.NewRecorder()
+	return rec, NewFiles(fsys).Serve(rec, req, "app.js")
+}
+
+func TestFilesServePrecompressed(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		wantEncoding   string
+		wantBody       string
+	}{
+		{acceptEncoding: "gzip, br", wantEncoding: "br", wantBody: "brotli"},
+		{acceptEncoding: "gzip", wantEncoding: "gzip", wantBody: "gzip"},
+		{acceptEncoding: "br;q=0, gzip", wantEncoding: "gzip", wantBody: "gzip"},
+		{acceptEncoding: "", wantEncoding: "", wantBody: "console.log(1)"},
+	}
+	for _, tt := range tests {
+		rec, err := serveFile(t, precompressedFiles, tt.acceptEncoding)
+		if err != nil {
+			t.Fatalf("Accept-Encoding %q: %v", tt.acceptEncoding, err)
+		}
+		header := rec.Header()
+		if got := header.Get("Content-Encoding"); got != tt.wantEncoding {
+			t.Errorf("Accept-Encoding %q: Content-Encoding = %q, want %q", tt.acceptEncoding, got, tt.wantEncoding)
+		}
+		if header.Get("Vary") != "Accept-Encoding" {
+			t.Errorf("Accept-Encoding %q: Vary = %q", tt.acceptEncoding, header.Get("Vary"))
+		}
+		if got := header.Get("Content-Type"); got != "text/javascript; charset=utf-8" {
+			t.Errorf("Accept-Encoding %q: Content-Type = %q", tt.acceptEncoding, got)
+		}
+		if rec.Body.String() != tt.wantBody {
+			t.Errorf("Accept-Encoding %q: body = %q, want %q", tt.acceptEncoding, rec.Body.String(), tt.wantBody)
+		}
+	}
+}
+
+func TestFilesServeUnreadablePrecompressed(t *testing.T) {
+	rec, err := serveFile(t, unreadableFS{MapFS: precompressedFiles, suffix: ".br"}, "br")
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("Serve() error = %v, want the open error", err)
+	}
+	for _, name := range []string{"Content-Encoding", "Vary", "ETag", "Content-Type"} {
+		if value := rec.Header().Get(name); value != "" {
+			t.Errorf("%s = %q left on a failed response", name, value)
+		}
+	}
+}
+
+func TestFilesServeMissing(t *testing.T) {
+	rec, err := serveFile(t, fstest.MapFS{}, "br")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Serve() error = %v, want fs.ErrNotExist", err)
+	}
+	if len(rec.Header()) != 0 || rec.Body.Len() != 0 {
+		t.Errorf("wrote %v %q for a missing file", rec.Header(), rec.Body.String())
+	}
+}
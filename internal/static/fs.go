@@ -0,0 +1,35 @@
+package static
+
+import (
+	"io/fs"
+	"log/slog"
+	"os"
+
+	"github.com/oj-lab/reborn/website"
+)
+
+// Sources of the website files
+const (
+	SourceDisk     = "disk"
+	SourceEmbedded = "embedded"
+)
+
+// OpenDist returns the website build to serve. A directory at distPath takes
+// precedence, so that development can override the build embedded with the
+// embedwebsite tag.
+func OpenDist(distPath string) (fs.FS, string) {
+	if distPath != "" {
+		if info, err := os.Stat(distPath); err == nil && info.IsDir() {
+			slog.Info("Serving website from disk", "path", distPath)
+			return os.DirFS(distPath), SourceDisk
+		}
+	}
+	if website.Embedded {
+		slog.Info("Serving embedded website")
+		return website.Dist(), SourceEmbedded
+	}
+
+	// Keep serving the API, pages answer 404 until the build appears
+	slog.Warn("Website build not found", "path", distPath)
+	return os.DirFS(distPath), SourceDisk
+}
@@ -0,0 +1,55 @@
+package static
+
+import (
+	"bytes"
+	"html"
+)
+
+// Head is the metadata of a page that link previews and search engines read
+// from the document head
+type Head struct {
+	Title       string
+	Description string
+	// SiteName is shown next to the title in link previews
+	SiteName string
+	// URL is the absolute canonical URL of the page
+	URL string
+	// Image is the absolute URL of the preview image
+	Image string
+	// Type is the Open Graph type, "website" when empty
+	Type string
+}
+
+// render returns the title, description, Open Graph and Twitter card tags
+func (h Head) render() []byte {
+	var b bytes.Buffer
+	b.WriteString("<title>" + html.EscapeString(h.Title) + "</title>")
+
+	meta := func(attr, name, content string) {
+		if content != "" {
+			b.WriteString(`<meta ` + attr + `="` + name + `" content="` +
+				html.EscapeString(content) + `" />`)
+		}
+	}
+	ogType := h.Type
+	if ogType == "" {
+		ogType = "website"
+	}
+	card := "summary"
+	if h.Image != "" {
+		card = "summary_large_image"
+	}
+
+	meta("name", "description", h.Description)
+	meta("property", "og:title", h.Title)
+	meta("property", "og:description", h.Description)
+	meta("property", "og:type", ogType)
+	meta("property", "og:site_name", h.SiteName)
+	meta("property", "og:url", h.URL)
+	meta("property", "og:image", h.Image)
+	meta("name", "twitter:card", card)
+	if h.URL != "" {
+		b.WriteString(`<link rel="canonical" href="` + html.EscapeString(h.URL) + `" />`)
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,160 @@
+package static
+
+import (
+	"bytes"
+	"io/fs"
+	"regexp"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// RuntimeConfigVar is the global the frontend reads its server configuration
+// from
+const RuntimeConfigVar = "window.__REBORN_CONFIG__"
+
+// Marks of the per-request parts of a compiled shell. NUL never appears in
+// HTML documents.
+const (
+	nonceMark = "\x00nonce\x00"
+	headMark  = "\x00head\x00"
+)
+
+var (
+	// nonceTagPattern matches the opening script and style tags of a document
+	nonceTagPattern = regexp.MustCompile(`(?i)<(script|style)(\s|>)`)
+	// titlePattern matches the title element, replaced by the page head
+	titlePattern = regexp.MustCompile(`(?is)<title>.*?</title>`)
+	// markPattern matches the marks of a compiled shell
+	markPattern = regexp.MustCompile("\x00(nonce|head)\x00")
+)
+
+// Shell renders the index.html of a website build for each request, with the
+// page head, the runtime configuration and the Content-Security-Policy
+// nonce. The document is only parsed again when the build changes.
+type Shell struct {
+	fsys          fs.FS
+	runtimeConfig []byte
+	compiled      atomic.Pointer[compiledShell]
+}
+
+// compiledShell is index.html split around the per-request parts: parts[i]
+// is followed by the part marked by marks[i]
+type compiledShell struct {
+	size    int64
+	modTime time.Time
+	parts   [][]byte
+	marks   []string
+}
+
+// NewShell creates a shell for the index.html of fsys, exposing the JSON
+// runtimeConfig to scripts
+func NewShell(fsys fs.FS, runtimeConfig []byte) *Shell {
+	return &Shell{fsys: fsys, runtimeConfig: runtimeConfig}
+}
+
+// Render returns index.html for a request with the metadata of head,
+// adding nonce to every script and style tag unless it is empty. It returns
+// an error wrapping fs.ErrNotExist when the build has no index.html.
+func (s *Shell) Render(head Head, nonce string) ([]byte, error) {
+	compiled, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return compiled.render(head, nonce), nil
+}
+
+// RenderHTML renders a document that is not part of the build, such as the
+// index.html of a frontend dev server, like Render
+func (s *Shell) RenderHTML(html []byte, head Head, nonce string) []byte {
+	return s.compile(html).render(head, nonce)
+}
+
+// load returns the compiled index.html, compiling it again when the file
+// changed on disk. Embedded files never change.
+func (s *Shell) load() (*compiledShell, error) {
+	info, err := fs.Stat(s.fsys, IndexFile)
+	if err != nil {
+		return nil, err
+	}
+	if cached := s.compiled.Load(); cached != nil &&
+		cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached, nil
+	}
+
+	html, err := fs.ReadFile(s.fsys, IndexFile)
+	if err != nil {
+		return nil, err
+	}
+	compiled := s.compile(html)
+	compiled.size = info.Size()
+	compiled.modTime = info.ModTime()
+	s.compiled.Store(compiled)
+	return compiled, nil
+}
+
+// compile splits a document around the per-request parts
+func (s *Shell) compile(html []byte) *compiledShell {
+	html = s.mark(html)
+	compiled := &compiledShell{}
+	start := 0
+	for _, loc := range markPattern.FindAllIndex(html, -1) {
+		compiled.parts = append(compiled.parts, html[start:loc[0]])
+		compiled.marks = append(compiled.marks, string(html[loc[0]:loc[1]]))
+		start = loc[1]
+	}
+	compiled.parts = append(compiled.parts, html[start:])
+	return compiled
+}
+
+// mark marks where the nonces and the page head go, and injects the
+// runtime configuration. The csp-nonce meta tag exposes the nonce to styles
+// and scripts inserted at runtime, e.g. by Vite's preload helper.
+func (s *Shell) mark(html []byte) []byte {
+	html = nonceTagPattern.ReplaceAll(html, []byte(`<$1`+nonceMark+`$2`))
+	titled := false
+	html = titlePattern.ReplaceAllFunc(html, func([]byte) []byte {
+		if titled {
+			return nil
+		}
+		titled = true
+		return []byte(headMark)
+	})
+
+	var head []byte
+	if !titled {
+		head = []byte(headMark)
+	}
+	head = slices.Concat(
+		head,
+		[]byte(`<meta property="csp-nonce"`+nonceMark+` />`),
+		[]byte(`<script`+nonceMark+`>`+RuntimeConfigVar+` = `),
+		s.runtimeConfig,
+		[]byte(`;</script>`),
+	)
+	i := bytes.Index(html, []byte("</head>"))
+	if i < 0 {
+		return slices.Concat(head, html)
+	}
+	return slices.Concat(html[:i], head, html[i:])
+}
+
+// render fills the per-request parts of a compiled document
+func (c *compiledShell) render(head Head, nonce string) []byte {
+	values := map[string][]byte{
+		headMark:  head.render(),
+		nonceMark: nil,
+	}
+	if nonce != "" {
+		values[nonceMark] = []byte(` nonce="` + nonce + `"`)
+	}
+
+	var b bytes.Buffer
+	for i, part := range c.parts {
+		b.Write(part)
+		if i < len(c.marks) {
+			b.Write(values[c.marks[i]])
+		}
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,142 @@
+package static
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+
+	config "github.com/oj-lab/reborn/configs"
+)
+
+// IndexFile is the SPA shell at the root of the website build
+const IndexFile = "index.html"
+
+// ErrInvalidPath is returned for URL paths that try to leave the root of the
+// website build
+var ErrInvalidPath = errors.New("invalid static file path")
+
+// Resolution tells how a request is answered from the website build
+type Resolution int
+
+const (
+	// ResolveSkip leaves the request to the other routes
+	ResolveSkip Resolution = iota
+	// ResolveFile serves an existing file
+	ResolveFile
+	// ResolveIndex serves the SPA shell for a client-side route
+	ResolveIndex
+	// ResolveNotFound answers 404 for a missing build file, such as a chunk
+	// of a previous deploy, instead of HTML the browser cannot use
+	ResolveNotFound
+)
+
+// Site maps request paths to the files of a single-page application build
+type Site struct {
+	files            *Files
+	excludedPrefixes []string
+	assetPrefixes    []string
+}
+
+// NewSite creates a site serving files with the path rules of cfg
+func NewSite(files *Files, cfg config.WebsiteConfig) *Site {
+	return &Site{
+		files:            files,
+		excludedPrefixes: cfg.ExcludedPrefixes,
+		assetPrefixes:    cfg.AssetPrefixes,
+	}
+}
+
+// Files returns the file server of the build
+func (s *Site) Files() *Files {
+	return s.files
+}
+
+// Resolve decides how to answer urlPath, returning the name of the file to
+// serve for ResolveFile. It returns ErrInvalidPath for traversal attempts.
+func (s *Site) Resolve(urlPath string) (Resolution, string, error) {
+	if s.Excluded(urlPath) {
+		return ResolveSkip, "", nil
+	}
+	name, err := CleanPath(urlPath)
+	if err != nil {
+		return ResolveSkip, "", err
+	}
+
+	dist := s.files.FS()
+	if info, err := fs.Stat(dist, name); err == nil {
+		if name == IndexFile {
+			// The shell is rendered per request, never served as is
+			return ResolveIndex, "", nil
+		}
+		if !info.IsDir() {
+			return ResolveFile, name, nil
+		}
+		indexName := path.Join(name, IndexFile)
+		if _, err := fs.Stat(dist, indexName); err == nil {
+			return ResolveFile, indexName, nil
+		}
+	}
+
+	if s.isBuildFile(urlPath, name) {
+		return ResolveNotFound, "", nil
+	}
+	if _, err := fs.Stat(dist, IndexFile); err == nil {
+		return ResolveIndex, "", nil
+	}
+	return ResolveSkip, "", nil
+}
+
+// Excluded reports whether urlPath belongs to the backend
+func (s *Site) Excluded(urlPath string) bool {
+	return hasAnyPrefix(urlPath, s.excludedPrefixes)
+}
+
+// Immutable reports whether the file is a content-hashed asset that can be
+// cached forever
+func (s *Site) Immutable(name string) bool {
+	return hasAnyPrefix("/"+name, s.assetPrefixes)
+}
+
+// isBuildFile reports whether a missing path can only have been a file of
+// the build: anything under the asset prefixes or with a web file extension.
+// Client-side routes have neither.
+func (s *Site) isBuildFile(urlPath, name string) bool {
+	if hasAnyPrefix(urlPath, s.assetPrefixes) || s.Immutable(name) {
+		return true
+	}
+	_, ok := contentTypes[path.Ext(name)]
+	return ok
+}
+
+// CleanPath maps a URL path to a file name in the website build. Paths with
+// ".." segments, backslashes or NUL bytes are rejected rather than cleaned,
+// so that they can never name a file outside the root.
+func CleanPath(urlPath string) (string, error) {
+	if strings.ContainsAny(urlPath, "\\\x00") {
+		return "", ErrInvalidPath
+	}
+	for _, segment := range strings.Split(urlPath, "/") {
+		if segment == ".." {
+			return "", ErrInvalidPath
+		}
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) {
+		return "", ErrInvalidPath
+	}
+	return name, nil
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package static
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"testing/fstest"
+
+	config "github.com/oj-lab/reborn/configs"
+)
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		want    string
+		wantErr bool
+	}{
+		{name: "root", target: "/", want: "."},
+		{name: "file", target: "/favicon.ico", want: "favicon.ico"},
+		{name: "nested file", target: "/assets/index-abc.js", want: "assets/index-abc.js"},
+		{name: "trailing slash", target: "/docs/", want: "docs"},
+		{name: "duplicate slashes", target: "//assets//index-abc.js", want: "assets/index-abc.js"},
+		{name: "dot segment", target: "/./assets/./index-abc.js", want: "assets/index-abc.js"},
+		{name: "absolute file system path", target: "/etc/passwd", want: "etc/passwd"},
+		{name: "absolute path with double slash", target: "//etc/passwd", want: "etc/passwd"},
+		{name: "dot dot dot is a name", target: "/.../x", want: ".../x"},
+		{name: "parent segment", target: "/../etc/passwd", wantErr: true},
+		{name: "nested parent segment", target: "/assets/../../etc/passwd", wantErr: true},
+		{name: "parent segment resolving inside", target: "/assets/../index.html", wantErr: true},
+		{name: "trailing parent segment", target: "/assets/..", wantErr: true},
+		{name: "encoded parent segment", target: "/%2e%2e/etc/passwd", wantErr: true},
+		{name: "mixed case encoded parent segment", target: "/assets/%2E%2e/%2e%2E/etc/passwd", wantErr: true},
+		{name: "half encoded parent segment", target: "/.%2e/etc/passwd", wantErr: true},
+		{name: "backslash", target: "/..\\etc\\passwd", wantErr: true},
+		{name: "encoded backslash", target: "/assets%5c..%5cetc%5cpasswd", wantErr: true},
+		{name: "windows drive", target: "/C:%5cWindows", wantErr: true},
+		{name: "encoded nul byte", target: "/index.html%00.js", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Paths arrive decoded, like http.Request.URL.Path
+			u, err := url.ParseRequestURI(tt.target)
+			if err != nil {
+				t.Fatalf("parse %q: %v", tt.target, err)
+			}
+
+			got, err := CleanPath(u.Path)
+			switch {
+			case tt.wantErr && !errors.Is(err, ErrInvalidPath):
+				t.Errorf("CleanPath(%q) = %q, %v, want ErrInvalidPath", u.Path, got, err)
+			case !tt.wantErr && err != nil:
+				t.Errorf("CleanPath(%q) failed: %v", u.Path, err)
+			case !tt.wantErr && got != tt.want:
+				t.Errorf("CleanPath(%q) = %q, want %q", u.Path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanPathRejectsRawNUL(t *testing.T) {
+	if _, err := CleanPath("/index.html\x00.js"); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("got %v, want ErrInvalidPath", err)
+	}
+}
+
+func newTestSite(withIndex bool) *Site {
+	dist := fstest.MapFS{
+		"favicon.ico":          {Data: []byte("icon")},
+		"robots.txt":           {Data: []byte("User-agent: *")},
+		"assets/index-abc.js":  {Data: []byte("console.log(1)")},
+		"assets/index-abc.css": {Data: []byte("body{}")},
+		"docs/index.html":      {Data: []byte("<html>docs</html>")},
+		"images/logo.png":      {Data: []byte("png")},
+	}
+	if withIndex {
+		dist[IndexFile] = &fstest.MapFile{Data: []byte("<html></html>")}
+	}
+	return NewSite(NewFiles(dist), config.WebsiteConfig{
+		ExcludedPrefixes: []string{"/api/", "/auth/", "/admin/", "/health"},
+		AssetPrefixes:    []string{"/assets/"},
+	})
+}
+
+func TestSiteResolve(t *testing.T) {
+	tests := []struct {
+		name      string
+		withIndex bool
+		urlPath   string
+		want      Resolution
+		wantName  string
+		wantErr   bool
+	}{
+		{name: "asset", urlPath: "/assets/index-abc.js", want: ResolveFile, wantName: "assets/index-abc.js"},
+		{name: "root file", urlPath: "/favicon.ico", want: ResolveFile, wantName: "favicon.ico"},
+		{name: "directory index", urlPath: "/docs/", want: ResolveFile, wantName: "docs/index.html"},
+		{name: "directory without slash", urlPath: "/docs", want: ResolveFile, wantName: "docs/index.html"},
+		{name: "shell is rendered", withIndex: true, urlPath: "/index.html", want: ResolveIndex},
+
+		{name: "missing asset", withIndex: true, urlPath: "/assets/index-old.js", want: ResolveNotFound},
+		{name: "missing asset without extension", withIndex: true, urlPath: "/assets/chunk", want: ResolveNotFound},
+		{name: "asset directory", withIndex: true, urlPath: "/assets/", want: ResolveNotFound},
+		{name: "missing file with web extension", withIndex: true, urlPath: "/images/missing.svg", want: ResolveNotFound},
+
+		{name: "client-side route", withIndex: true, urlPath: "/problems/42", want: ResolveIndex},
+		{name: "client-side route with dots", withIndex: true, urlPath: "/users/jane.doe", want: ResolveIndex},
+		{name: "absolute path", withIndex: true, urlPath: "/etc/passwd", want: ResolveIndex},
+		{name: "client-side route without build", urlPath: "/problems/42", want: ResolveSkip},
+
+		{name: "api", withIndex: true, urlPath: "/api/v1/users", want: ResolveSkip},
+		{name: "admin", withIndex: true, urlPath: "/admin/users", want: ResolveSkip},
+		{name: "health", withIndex: true, urlPath: "/health/ready", want: ResolveSkip},
+		{name: "excluded traversal is left to its route", withIndex: true, urlPath: "/api/../index.html", want: ResolveSkip},
+
+		{name: "traversal", withIndex: true, urlPath: "/assets/../../etc/passwd", wantErr: true},
+		{name: "backslash", withIndex: true, urlPath: "/assets\\index-abc.js", wantErr: true},
+		{name: "nul byte", withIndex: true, urlPath: "/favicon.ico\x00", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, name, err := newTestSite(tt.withIndex).Resolve(tt.urlPath)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPath) {
+					t.Errorf("Resolve(%q) error = %v, want ErrInvalidPath", tt.urlPath, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve(%q) failed: %v", tt.urlPath, err)
+			}
+			if got != tt.want || name != tt.wantName {
+				t.Errorf("Resolve(%q) = %v, %q, want %v, %q", tt.urlPath, got, name, tt.want, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestSiteImmutable(t *testing.T) {
+	site := newTestSite(true)
+	for name, want := range map[string]bool{
+		"assets/index-abc.js": true,
+		"favicon.ico":         false,
+		"docs/index.html":     false,
+	} {
+		if got := site.Immutable(name); got != want {
+			t.Errorf("Immutable(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package telemetry
+
+import (
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewSampler(t *testing.T) {
+	sampledParent := trace.ContextWithRemoteSpanContext(t.Context(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}))
+	// Ratio sampling compares the low half of the ID, here above any
+	// threshold below 1
+	rootTraceID := trace.TraceID{8: 0xff, 9: 0xff, 10: 0xff, 11: 0xff, 12: 0xff, 13: 0xff, 14: 0xff, 15: 0xff}
+
+	tests := []struct {
+		name       string
+		ratio      float64
+		wantRoot   sdktrace.SamplingDecision
+		wantParent sdktrace.SamplingDecision
+	}{
+		{name: "zero records nothing", ratio: 0,
+			wantRoot: sdktrace.Drop, wantParent: sdktrace.Drop},
+		{name: "ratio follows sampled parents", ratio: 0.5,
+			wantRoot: sdktrace.Drop, wantParent: sdktrace.RecordAndSample},
+		{name: "one records everything", ratio: 1,
+			wantRoot: sdktrace.RecordAndSample, wantParent: sdktrace.RecordAndSample},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sampler := newSampler(tt.ratio)
+
+			root := sampler.ShouldSample(sdktrace.SamplingParameters{
+				ParentContext: t.Context(),
+				TraceID:       rootTraceID,
+			})
+			if root.Decision != tt.wantRoot {
+				t.Errorf("root span: decision %v, want %v", root.Decision, tt.wantRoot)
+			}
+
+			child := sampler.ShouldSample(sdktrace.SamplingParameters{
+				ParentContext: sampledParent,
+				TraceID:       trace.TraceID{1},
+			})
+			if child.Decision != tt.wantParent {
+				t.Errorf("span with a sampled parent: decision %v, want %v", child.Decision, tt.wantParent)
+			}
+		})
+	}
+}
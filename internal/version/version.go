@@ -0,0 +1,42 @@
+package version
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// Version is the release of this build, set with
+// -ldflags "-X github.com/oj-lab/reborn/internal/version.Version=v1.2.3"
+var Version = "dev"
+
+// Info describes the running build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns information about the running build, using the VCS details
+// recorded by the Go toolchain
+var Get = sync.OnceValue(func() Info {
+	info := Info{Version: Version}
+
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.GoVersion = buildInfo.GoVersion
+	for _, setting := range buildInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Commit = setting.Value
+		case "vcs.time":
+			info.BuildTime = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+})
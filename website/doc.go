@@ -0,0 +1,4 @@
+// Package website holds the frontend application. Building with the
+// embedwebsite tag compiles its production build from website/dist into the
+// binary, see Dist.
+package website
@@ -0,0 +1,24 @@
+//go:build embedwebsite
+
+package website
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// Embedded reports whether the production build is compiled into the binary
+const Embedded = true
+
+//go:embed all:dist
+var dist embed.FS
+
+// Dist returns the production build of the website, i.e. the contents of
+// website/dist when the binary was built
+func Dist() fs.FS {
+	sub, err := fs.Sub(dist, "dist")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
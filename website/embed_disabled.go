@@ -0,0 +1,13 @@
+//go:build !embedwebsite
+
+package website
+
+import "io/fs"
+
+// Embedded reports whether the production build is compiled into the binary
+const Embedded = false
+
+// Dist returns nil, the binary was built without the embedwebsite tag
+func Dist() fs.FS {
+	return nil
+}
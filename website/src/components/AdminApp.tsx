@@ -4,6 +4,7 @@ import AdminLayout from '@/components/AdminLayout'
 import Dashboard from '@/pages/Dashboard'
 import UserManagement from '@/pages/UserManagement'
 import ThemeDemo from '@/pages/ThemeDemo'
+import { isFeatureEnabled } from '@/lib/runtime-config'
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '@/components/ui/card'
 
 // Temporary page component
@@ -105,7 +106,9 @@ export default function AdminApp() {
         <Route path="/data" element={<DataManagementPage />} />
         <Route path="/settings" element={<SettingsPage />} />
         <Route path="/analytics" element={<AnalyticsPage />} />
-        <Route path="/theme-demo" element={<ThemeDemo />} />
+        {isFeatureEnabled('theme_demo') && (
+          <Route path="/theme-demo" element={<ThemeDemo />} />
+        )}
         <Route path="*" element={<Navigate to="/admin/dashboard" replace />} />
       </Routes>
     </AdminLayout>
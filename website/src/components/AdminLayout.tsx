@@ -30,6 +30,7 @@ import {
 import { LanguageSwitcher } from "@/components/LanguageSwitcher";
 import { ModeToggle } from "@/components/mode-toggle";
 import { ColorThemeToggle } from "@/components/color-theme-toggle";
+import { isFeatureEnabled, runtimeConfig } from "@/lib/runtime-config";
 import {
   LayoutDashboard,
   Users,
@@ -98,11 +99,15 @@ const useMenuItems = () => {
           icon: BarChart3,
           url: "/admin/analytics",
         },
-        {
-          title: t("theme.demo.title", "主题演示"),
-          icon: Palette,
-          url: "/admin/theme-demo",
-        },
+        ...(isFeatureEnabled("theme_demo")
+          ? [
+              {
+                title: t("theme.demo.title", "主题演示"),
+                icon: Palette,
+                url: "/admin/theme-demo",
+              },
+            ]
+          : []),
       ],
     },
   ];
@@ -141,7 +146,7 @@ export default function AdminLayout({
                 <Shield className="size-4" />
               </div>
               <div className="grid flex-1 text-left text-sm leading-tight">
-                <span className="truncate font-semibold">Reborn Admin</span>
+                <span className="truncate font-semibold">{runtimeConfig.title} Admin</span>
                 <span className="truncate text-xs text-muted-foreground">
                   {t("layout.adminSystem")}
                 </span>
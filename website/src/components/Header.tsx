@@ -17,6 +17,12 @@ import { LanguageSwitcher } from '@/components/LanguageSwitcher'
 import { useTranslation } from 'react-i18next'
 import { useNavigate } from 'react-router-dom'
 import { UserpbUserRole } from '@/api/api'
+import { runtimeConfig } from '@/lib/runtime-config'
+
+// Display names of the OAuth providers the backend may offer
+const providerNames: Record<string, string> = {
+  github: 'GitHub',
+}
 
 const Header: React.FC = () => {
   const { user, loading, login, logout, isAuthenticated } = useAuth()
@@ -41,7 +47,7 @@ const Header: React.FC = () => {
             className="text-2xl font-bold text-foreground hover:text-primary transition-colors duration-300 cursor-pointer"
             onClick={handleHome}
           >
-            OJ Lab
+            {runtimeConfig.title}
           </h1>
         </div>
         
@@ -105,10 +111,18 @@ const Header: React.FC = () => {
               </DropdownMenuContent>
             </DropdownMenu>
           ) : (
-            <Button onClick={login} className="flex items-center space-x-2">
-              <GitHubIcon className="h-4 w-4" />
-              <span>Login with GitHub</span>
-            </Button>
+            runtimeConfig.login_providers.map((provider) => (
+              <Button
+                key={provider}
+                onClick={() => login(provider)}
+                className="flex items-center space-x-2"
+              >
+                {provider === 'github' && <GitHubIcon className="h-4 w-4" />}
+                <span>
+                  {t('auth.loginWith', { provider: providerNames[provider] ?? provider })}
+                </span>
+              </Button>
+            ))
           )}
         </div>
       </div>
@@ -1,9 +1,12 @@
 import { useEffect } from 'react'
+import { cspNonce } from '@/lib/csp-nonce'
+import { runtimeConfig } from '@/lib/runtime-config'
 
 export default function LandingPage() {
   useEffect(() => {
     // Add custom CSS animations
     const style = document.createElement('style')
+    if (cspNonce) style.nonce = cspNonce
     style.textContent = `
       @keyframes fadeInSlideDown {
         from {
@@ -385,7 +388,7 @@ export default function LandingPage() {
         <div className="text-center space-y-8">
           <div className="opacity-0 animate-title">
             <h1 className="text-8xl md:text-9xl lg:text-[12rem] font-bold text-foreground mb-6 tracking-tight title-glow cursor-default">
-              OJ Lab
+              {runtimeConfig.title}
             </h1>
           </div>
           <div className="opacity-0 animate-subtitle">
@@ -8,6 +8,7 @@ interface ThemeProviderProps {
   defaultTheme?: string
   enableSystem?: boolean
   disableTransitionOnChange?: boolean
+  nonce?: string
 }
 
 export function ThemeProvider({ 
@@ -1,6 +1,7 @@
 import * as React from "react"
 import * as RechartsPrimitive from "recharts"
 
+import { cspNonce } from "@/lib/csp-nonce"
 import { cn } from "@/lib/utils"
 
 // Format: { THEME_NAME: CSS_SELECTOR }
@@ -78,6 +79,7 @@ const ChartStyle = ({ id, config }: { id: string; config: ChartConfig }) => {
 
   return (
     <style
+      nonce={cspNonce}
       dangerouslySetInnerHTML={{
         __html: Object.entries(THEMES)
           .map(
@@ -1,6 +1,7 @@
 import React, { useCallback, useEffect, useState, useMemo } from 'react'
 import type { ReactNode } from 'react'
 import { UserApi, type UserpbUser } from '@/api/api'
+import { runtimeConfig } from '@/lib/runtime-config'
 import { AuthContext, type AuthContextType } from './auth-context'
 
 interface AuthProviderProps {
@@ -24,8 +25,8 @@ export const AuthProvider: React.FC<AuthProviderProps> = ({ children }) => {
     }
   }, [])
 
-  const login = useCallback(() => {
-    window.location.href = '/auth/login?provider=github'
+  const login = useCallback((provider = runtimeConfig.login_providers[0] ?? 'github') => {
+    window.location.href = `/auth/login?provider=${encodeURIComponent(provider)}`
   }, [])
 
   const logout = useCallback(() => {
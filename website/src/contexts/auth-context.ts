@@ -4,7 +4,8 @@ import type { UserpbUser } from '@/api/api'
 export interface AuthContextType {
   user: UserpbUser | null
   loading: boolean
-  login: () => void
+  // login starts the OAuth flow, with the first configured provider by default
+  login: (provider?: string) => void
   logout: () => void
   fetchUser: () => Promise<void>
   isAuthenticated: boolean
@@ -133,6 +133,7 @@ const zhCN = {
   },
   auth: {
     logout: '退出登录',
+    loginWith: '使用 {{provider}} 登录',
   },
   layout: {
     adminSystem: '后台管理系统',
@@ -311,6 +312,7 @@ const enUS = {
   },
   auth: {
     logout: 'Log out',
+    loginWith: 'Login with {{provider}}',
   },
   layout: {
     adminSystem: 'Admin System',
@@ -363,6 +365,20 @@ const resources = {
   'en-US': { translation: enUS },
 }
 
+// Merge the server catalog (e.g. API error codes) into the local resources
+const loadServerMessages = async (lng: string) => {
+  try {
+    const response = await fetch(`/api/v1/i18n/${encodeURIComponent(lng)}`)
+    if (response.ok) {
+      i18n.addResourceBundle(lng, 'translation', await response.json(), true, false)
+    }
+  } catch (error) {
+    console.error('Failed to load server translations:', error)
+  }
+}
+
+i18n.on('languageChanged', loadServerMessages)
+
 i18n
   .use(LanguageDetector)
   .use(initReactI18next)
@@ -375,7 +391,9 @@ i18n
     },
     detection: {
       order: ['localStorage', 'navigator', 'htmlTag'],
-      caches: ['localStorage'],
+      // The cookie lets the server localize API errors the same way
+      caches: ['localStorage', 'cookie'],
+      lookupCookie: 'lang',
     },
   })
 
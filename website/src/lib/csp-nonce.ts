@@ -0,0 +1,13 @@
+// Nonce of the Content-Security-Policy, exposed by the backend in a
+// <meta property="csp-nonce"> tag. Inline <style> elements only apply with it.
+export const cspNonce: string | undefined =
+  document.querySelector<HTMLMetaElement>('meta[property="csp-nonce"]')?.nonce || undefined
+
+declare global {
+  // Read by get-nonce, through which the Radix scroll lock injects its styles
+  var __webpack_nonce__: string | undefined
+}
+
+if (cspNonce) {
+  globalThis.__webpack_nonce__ = cspNonce
+}
@@ -0,0 +1,33 @@
+// Server configuration injected into index.html by the backend
+export interface RuntimeConfig {
+  title: string
+  public_url: string
+  login_providers: string[]
+  features: Record<string, boolean>
+  version: string
+}
+
+declare global {
+  interface Window {
+    __REBORN_CONFIG__?: Partial<RuntimeConfig>
+  }
+}
+
+// Defaults for pages not served by the backend, e.g. the Vite dev server
+const defaults: RuntimeConfig = {
+  title: 'OJ Lab',
+  public_url: '',
+  login_providers: ['github'],
+  features: {},
+  version: 'dev',
+}
+
+export const runtimeConfig: RuntimeConfig = {
+  ...defaults,
+  ...(typeof window !== 'undefined' ? window.__REBORN_CONFIG__ : undefined),
+}
+
+// Whether a frontend feature flag is enabled
+export function isFeatureEnabled(name: string): boolean {
+  return runtimeConfig.features[name] === true
+}
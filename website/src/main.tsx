@@ -1,6 +1,7 @@
 import { StrictMode } from 'react'
 import { createRoot } from 'react-dom/client'
 import './index.css'
+import { cspNonce } from './lib/csp-nonce'
 import './i18n'
 import './lib/theme-init'
 import App from './App.tsx'
@@ -13,6 +14,7 @@ createRoot(document.getElementById('root')!).render(
       defaultTheme="system"
       enableSystem
       disableTransitionOnChange
+      nonce={cspNonce}
     >
       <App />
     </ThemeProvider>